@@ -0,0 +1,75 @@
+// fakekaspi - локальная замена эндпоинта офферов Kaspi для разработки и проверки fetcher.
+// Отдает офферы из файла в формате json.txt (несколько запросов save-kaspi-data,
+// разделенных строками из дефисов) с постраничной разбивкой, как настоящий эндпоинт.
+//
+//	go run ./cmd/fakekaspi -file json.txt -addr :8090
+//
+// и в config.yaml: fetcher.url: http://localhost:8090/yml/offer-view/offers/{productId}
+package main
+
+import (
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/importer"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+)
+
+type pageRequest struct {
+	Limit int `json:"limit"`
+	Page  int `json:"page"`
+}
+
+func main() {
+	file := flag.String("file", "json.txt", "file with recorded save-kaspi-data payloads")
+	addr := flag.String("addr", ":8090", "listen address")
+	flag.Parse()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *file, err)
+	}
+
+	// Для каждого товара отдаем последнюю запись из файла
+	products := make(map[string]models.ProductOffers)
+	err = importer.ReadKaspiRequests(f, func(_ int, request *models.KaspiDataRequest) error {
+		products[request.ProductID] = request.Offers
+		return nil
+	})
+	f.Close()
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", *file, err)
+	}
+	log.Printf("Loaded %d products from %s", len(products), *file)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/yml/offer-view/offers/{productId}", func(w http.ResponseWriter, r *http.Request) {
+		offers, ok := products[mux.Vars(r)["productId"]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		page := pageRequest{Limit: 64}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&page)
+		}
+		if page.Limit <= 0 {
+			page.Limit = 64
+		}
+
+		start := min(page.Page*page.Limit, len(offers.Offers))
+		end := min(start+page.Limit, len(offers.Offers))
+		offers.Offers = offers.Offers[start:end]
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(offers)
+	}).Methods("POST")
+
+	log.Printf("Fake Kaspi offers endpoint listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, router))
+}
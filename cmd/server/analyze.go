@@ -0,0 +1,92 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"Mini-Quicko/internal/core/models"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// runAnalyze реализует подкоманду `analyze`:
+//
+//	server analyze -city 710000000 -json 121806358
+func runAnalyze(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	cityID := fs.String("city", "", "Kaspi city ID (default: analysis.default_city_id)")
+	workspace := fs.String("workspace", models.DefaultWorkspaceID, "workspace ID")
+	asJSON := fs.Bool("json", false, "print the analysis as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s analyze [flags] <productId>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("exactly one product ID is required")
+	}
+
+	repo, svc, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	analysis, err := svc.AnalyzeProduct(ctx, *workspace, fs.Arg(0), *cityID)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(analysis)
+	}
+	return printAnalysis(analysis)
+}
+
+func printAnalysis(analysis *models.ProductAnalysis) error {
+	fmt.Printf("Product %s, city %s: %d sellers\n\n", analysis.ProductID, analysis.CityID, len(analysis.Sellers))
+	fmt.Printf("  Min / median / max:   %.0f / %.0f / %.0f\n", analysis.MinPrice, analysis.MedianPrice, analysis.MaxPrice)
+	fmt.Printf("  Average (weighted):   %.0f (%.0f)\n", analysis.AvgPrice, analysis.WeightedAvgPrice)
+	fmt.Printf("  P10 / P90, IQR:       %.0f / %.0f, %.0f\n", analysis.P10Price, analysis.P90Price, analysis.IQR)
+	fmt.Printf("  Spread:               %.1f%%\n", analysis.PriceSpreadPercent)
+	fmt.Printf("  Optimal price:        %.0f\n", analysis.OptimalPrice)
+	fmt.Printf("  Dumping / noise:      %d / %d\n\n", len(analysis.DumpingSellers), len(analysis.NoiseSellers))
+
+	dumping := make(map[string]bool, len(analysis.DumpingSellers))
+	for _, seller := range analysis.DumpingSellers {
+		dumping[seller.ID] = true
+	}
+	noise := make(map[string]bool, len(analysis.NoiseSellers))
+	for _, seller := range analysis.NoiseSellers {
+		noise[seller.ID] = true
+	}
+	topSlot := make(map[string]models.BuyBoxEstimate)
+	if analysis.BuyBox != nil {
+		for _, estimate := range analysis.BuyBox.Sellers {
+			topSlot[estimate.SellerID] = estimate
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tSELLER\tPRICE\tRATING\tREVIEWS\tSCORE\tP(FIRST)\tTOP SLOT PRICE\tFLAGS")
+	for _, seller := range analysis.Competitors {
+		var flags []string
+		if dumping[seller.ID] {
+			flags = append(flags, "dumping")
+		}
+		if noise[seller.ID] {
+			flags = append(flags, "noise")
+		}
+		estimate := topSlot[seller.ID]
+		fmt.Fprintf(tw, "%d\t%s\t%.0f\t%.1f\t%d\t%.1f\t%.2f\t%.0f\t%s\n", seller.Rank, seller.Name, seller.Price, seller.Rating,
+			seller.Reviews, seller.Score, estimate.Probability, estimate.TopSlotPrice, strings.Join(flags, ","))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/service"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runBacktest реализует подкоманду `backtest`:
+//
+//	server backtest -product 121806358 -strategies current,undercut -merchant 30358551 -cost 150000
+func runBacktest(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	workspace := fs.String("workspace", models.DefaultWorkspaceID, "workspace ID")
+	productID := fs.String("product", "", "product ID to replay")
+	tag := fs.String("tag", "", "replay every watchlist product with this tag")
+	cityID := fs.String("city", "", "Kaspi city ID (default: analysis.default_city_id)")
+	strategies := fs.String("strategies", "", "comma-separated strategies: "+strings.Join(service.PricingStrategies(), ", ")+" (default: all)")
+	merchantID := fs.String("merchant", "", "our merchant ID, excluded from competitors")
+	unitCost := fs.Float64("cost", 0, "unit cost used to compute margin")
+	from := fs.String("from", "", "start of the period (YYYY-MM-DD)")
+	to := fs.String("to", "", "end of the period (YYYY-MM-DD)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	if *productID == "" && *tag == "" {
+		fs.Usage()
+		return fmt.Errorf("-product or -tag is required")
+	}
+
+	request := &models.BacktestRequest{
+		CityID:     *cityID,
+		MerchantID: *merchantID,
+		UnitCost:   *unitCost,
+	}
+	if *strategies != "" {
+		request.Strategies = strings.Split(*strategies, ",")
+	}
+
+	var err error
+	if *from != "" {
+		if request.From, err = time.Parse(time.DateOnly, *from); err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+	}
+	if *to != "" {
+		if request.To, err = time.Parse(time.DateOnly, *to); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+	}
+
+	repo, svc, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	productIDs := []string{}
+	if *productID != "" {
+		productIDs = append(productIDs, *productID)
+	}
+	if *tag != "" {
+		entries, err := svc.GetWatchlist(ctx, *workspace, []string{*tag})
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			productIDs = append(productIDs, entry.ProductID)
+		}
+	}
+
+	for _, id := range productIDs {
+		request.ProductID = id
+		report, err := svc.Backtest(ctx, *workspace, request)
+		if err != nil {
+			// При переборе по тегу пропускаем товары без истории
+			if *tag != "" {
+				fmt.Fprintf(os.Stderr, "Product %s: %v\n", id, err)
+				continue
+			}
+			return err
+		}
+		if err := printBacktestReport(report, *asJSON); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printBacktestReport(report *models.BacktestReport, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("Product %s, city %s: %d snapshots from %s to %s\n\n", report.ProductID, report.CityID, report.Snapshots,
+		report.From.Format(time.RFC3339), report.To.Format(time.RFC3339))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "STRATEGY\tCHEAPEST %\tAVG PRICE\tAVG PREMIUM %\tAVG MARGIN %\tPRICE CHANGES\t")
+	for _, result := range report.Results {
+		fmt.Fprintf(tw, "%s\t%.1f\t%.0f\t%.2f\t%.2f\t%d\t\n", result.Strategy, result.CheapestRate*100,
+			result.AvgPrice, result.AvgPremiumPercent, result.AvgMarginPercent, result.PriceChanges)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/export"
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// runExport реализует подкоманду `export` - выгрузку истории цен в NDJSON, CSV или XLSX:
+//
+//	server export -product 121806358 -from 2024-01-01 -o history.ndjson
+//	server export -tag tv -format xlsx -lang en -o history.xlsx
+func runExport(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	workspace := fs.String("workspace", models.DefaultWorkspaceID, "workspace ID")
+	products := fs.String("product", "", "comma-separated product IDs (default: all)")
+	tag := fs.String("tag", "", "export watchlist products with this tag")
+	cityID := fs.String("city", "", "Kaspi city ID (default: all cities)")
+	from := fs.String("from", "", "start of the period (YYYY-MM-DD)")
+	to := fs.String("to", "", "end of the period (YYYY-MM-DD)")
+	output := fs.String("o", "", "output file (default: stdout)")
+	format := fs.String("format", "ndjson", "output format: ndjson, csv or xlsx")
+	lang := fs.String("lang", "ru", "column headers language for csv and xlsx: ru or en")
+	fs.Parse(args)
+
+	var table export.Format
+	if *format != "ndjson" {
+		var err error
+		if table, err = export.ParseFormat(*format); err != nil {
+			return err
+		}
+	}
+
+	filter := models.HistoryFilter{CityID: *cityID}
+	if *products != "" {
+		filter.ProductIDs = strings.Split(*products, ",")
+	}
+
+	var err error
+	if *from != "" {
+		if filter.From, err = time.Parse(time.DateOnly, *from); err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+	}
+	if *to != "" {
+		if filter.To, err = time.Parse(time.DateOnly, *to); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+		// Дата окончания включается целиком
+		filter.To = filter.To.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	repo, svc, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	if *tag != "" {
+		entries, err := svc.GetWatchlist(ctx, *workspace, []string{*tag})
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("no watchlist products with tag %q", *tag)
+		}
+		for _, entry := range entries {
+			filter.ProductIDs = append(filter.ProductIDs, entry.ProductID)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	writer := bufio.NewWriter(out)
+	var writeRow func(history models.PriceHistory) error
+	finish := func() error { return nil }
+	if table == "" {
+		encoder := json.NewEncoder(writer)
+		writeRow = func(history models.PriceHistory) error {
+			return encoder.Encode(history)
+		}
+	} else {
+		sheet, err := export.NewWriter(writer, table, export.ParseLang(*lang), "history", export.HistoryColumns)
+		if err != nil {
+			return err
+		}
+		finish = sheet.Close
+		writeRow = func(history models.PriceHistory) error {
+			return sheet.WriteRow(export.HistoryRow(history)...)
+		}
+	}
+
+	rows := 0
+	err = svc.ExportPriceHistory(ctx, *workspace, filter, func(history models.PriceHistory) error {
+		rows++
+		return writeRow(history)
+	})
+	if err != nil {
+		return err
+	}
+	if err := finish(); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d price history rows\n", rows)
+	return nil
+}
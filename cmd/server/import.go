@@ -0,0 +1,203 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/importer"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runImport реализует подкоманду `import` - загрузку файла с запросами save-kaspi-data
+// (json.txt, NDJSON, .gz) или таблицы CSV/XLSX через тот же конвейер, что и HTTP API:
+//
+//	server import -city 710000000 json.txt
+//	server import -map "price=Цена тг,merchantId=ID магазина" offers.xlsx
+func runImport(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	workspace := fs.String("workspace", models.DefaultWorkspaceID, "workspace ID")
+	cityID := fs.String("city", "", "Kaspi city ID for payloads without city_id")
+	async := fs.Bool("async", false, "enqueue payloads for the ingestion workers instead of processing them now")
+	asJSON := fs.Bool("json", false, "print the summary as JSON")
+	format := fs.String("format", "", "csv or xlsx (default: by file extension, otherwise JSON payloads)")
+	columns := fs.String("map", "", "CSV/XLSX column mapping over import.columns: field=column,...")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s import [flags] <file|->\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("exactly one file is required")
+	}
+	path := fs.Arg(0)
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	table, isTable := importer.DetectFormat(*format)
+	if *format == "" {
+		table, isTable = importer.DetectFormat(strings.TrimSuffix(path, ".gz"))
+	} else if !isTable {
+		return fmt.Errorf("unsupported -format %q: expected csv or xlsx", *format)
+	}
+
+	repo, svc, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	if _, err := svc.GetWorkspace(ctx, *workspace); err != nil {
+		return err
+	}
+	if isTable {
+		return importTable(ctx, svc, cfg, in, table, *workspace, *columns, *cityID, *async, *asJSON)
+	}
+
+	summary := models.IngestSummary{Results: []models.IngestResult{}}
+	err = importer.ReadKaspiRequests(in, func(index int, request *models.KaspiDataRequest) error {
+		if request.CityID == "" {
+			request.CityID = *cityID
+		}
+
+		result := models.IngestResult{Line: index, ProductID: request.ProductID, Status: "error", Offers: len(request.Offers.Offers)}
+		switch {
+		case request.ProductID == "":
+			result.Error = "Product ID is required"
+		case len(request.Offers.Offers) == 0:
+			result.Error = "No offers provided"
+		case *async:
+			if _, err := svc.EnqueueKaspiData(ctx, *workspace, request); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Status = "queued"
+			}
+		default:
+			if analysis, err := svc.SaveKaspiData(ctx, *workspace, request); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Status = "ok"
+				result.Quarantined = len(analysis.QuarantinedOffers)
+				result.MinPrice = analysis.MinPrice
+				result.OptimalPrice = analysis.OptimalPrice
+			}
+		}
+
+		summary.Lines++
+		if result.Error == "" {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, result)
+		if !*asJSON {
+			printIngestResult(result)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	}
+
+	fmt.Printf("\nImported %d payloads: %d succeeded, %d failed\n", summary.Lines, summary.Succeeded, summary.Failed)
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d payloads failed", summary.Failed)
+	}
+	return nil
+}
+
+func printIngestResult(result models.IngestResult) {
+	switch result.Status {
+	case "ok":
+		fmt.Printf("#%d product %s: %d offers, %d quarantined, min %.0f, optimal %.0f\n", result.Line, result.ProductID,
+			result.Offers, result.Quarantined, result.MinPrice, result.OptimalPrice)
+	case "queued":
+		fmt.Printf("#%d product %s: %d offers queued\n", result.Line, result.ProductID, result.Offers)
+	default:
+		fmt.Printf("#%d product %s: %s\n", result.Line, result.ProductID, result.Error)
+	}
+}
+
+// importTable загружает офферы из CSV/XLSX и печатает ошибки строк и результат по товарам
+func importTable(ctx context.Context, svc ports.Service, cfg *config.Config, in io.Reader, format importer.Format,
+	workspaceID, columns, cityID string, async, asJSON bool) error {
+
+	if async {
+		return fmt.Errorf("-async is not supported for CSV/XLSX import")
+	}
+
+	mapping, err := importer.NewMapping(cfg.ImportColumns)
+	if err != nil {
+		return fmt.Errorf("invalid import.columns: %w", err)
+	}
+	if columns != "" {
+		override, err := importer.ParseMapping(columns)
+		if err != nil {
+			return err
+		}
+		mapping = mapping.Merge(override)
+	}
+
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	rows, err := importer.ReadTable(data, format)
+	if err != nil {
+		return err
+	}
+
+	summary, err := importer.ImportTable(ctx, svc, workspaceID, rows, mapping, cityID)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	}
+
+	for _, rowError := range summary.Errors {
+		fmt.Printf("row %d, %s %q: %s\n", rowError.Row, rowError.Column, rowError.Value, rowError.Message)
+	}
+	for _, result := range summary.Products {
+		printIngestResult(result)
+	}
+	fmt.Printf("\nImported %d rows (%d invalid): %d products succeeded, %d failed\n",
+		summary.Rows, summary.Invalid, summary.Succeeded, summary.Failed)
+	if summary.Invalid > 0 || summary.Failed > 0 {
+		return fmt.Errorf("%d invalid rows, %d failed products", summary.Invalid, summary.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"Mini-Quicko/internal/core/models"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runKeys реализует подкоманду `keys` - управление API ключами:
+//
+//	server keys create -workspace team-a -name scraper -roles ingest
+//	server keys list -workspace team-a
+//	server keys revoke 3
+func runKeys(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: keys create|list|revoke")
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("keys "+action, flag.ExitOnError)
+	workspace := fs.String("workspace", models.DefaultWorkspaceID, "workspace ID")
+	name := fs.String("name", "", "key name, e.g. scraper or dashboard (create)")
+	roles := fs.String("roles", "", "comma-separated roles: ingest, read, admin (create)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	repo, svc, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	switch action {
+	case "create":
+		request := &models.CreateAPIKeyRequest{Name: *name}
+		for _, role := range strings.Split(*roles, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				request.Roles = append(request.Roles, models.Role(role))
+			}
+		}
+
+		key, err := svc.CreateAPIKey(ctx, *workspace, request)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(key)
+		}
+		fmt.Printf("Created API key %d (%s) in workspace %s with roles %s\n", key.ID, key.Name, key.WorkspaceID, formatRoles(key.Roles))
+		fmt.Printf("Key: %s\n", key.Key)
+		fmt.Println("Store it now: the key is not shown again.")
+		return nil
+
+	case "list":
+		keys, err := svc.ListAPIKeys(ctx, *workspace)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(keys)
+		}
+
+		table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tNAME\tPREFIX\tROLES\tCREATED\tLAST USED\tREVOKED")
+		for _, key := range keys {
+			fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, formatRoles(key.Roles),
+				key.CreatedAt.Format(time.DateTime), formatOptionalTime(key.LastUsedAt), formatOptionalTime(key.RevokedAt))
+		}
+		return table.Flush()
+
+	case "revoke":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: keys revoke <id>")
+		}
+		id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid key ID %q", fs.Arg(0))
+		}
+		if err := svc.RevokeAPIKey(ctx, *workspace, id); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked API key %d\n", id)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown keys action %q: use create, list or revoke", action)
+	}
+}
+
+func formatRoles(roles []models.Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return strings.Join(names, ",")
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.DateTime)
+}
@@ -2,50 +2,142 @@ package main
 
 import (
 	"Mini-Quicko/config"
-	"Mini-Quicko/internal/handlers"
+	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/logging"
 	"Mini-Quicko/internal/repository"
 	"Mini-Quicko/internal/service"
+	"context"
 	"fmt"
-	"log"
-	"net/http"
-
-	"github.com/gorilla/mux"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
 )
 
+// command - подкоманда CLI. Все подкоманды используют общую конфигурацию config.yaml.
+// Контекст отменяется по SIGINT/SIGTERM.
+type command struct {
+	usage string
+	run   func(ctx context.Context, cfg *config.Config, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":      {"start the HTTP API, ingestion workers and fetch scheduler (default)", runServe},
+	"migrate":    {"apply database migrations (-status lists pending ones)", runMigrate},
+	"import":     {"ingest a file of save-kaspi-data payloads (json.txt, NDJSON)", runImport},
+	"analyze":    {"print the price analysis of a product", runAnalyze},
+	"export":     {"export price history as NDJSON", runExport},
+	"purge":      {"delete data older than the retention period", runPurge},
+	"backtest":   {"replay pricing strategies against stored snapshots", runBacktest},
+	"keys":       {"manage API keys of a workspace (create, list, revoke)", runKeys},
+	"workspaces": {"manage team workspaces (create, update, list)", runWorkspaces},
+}
+
 func main() {
-	// Загрузка конфигурации
-	cfg := config.Load()
+	// Без подкоманды запускаем сервер, как и раньше
+	name := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	// Подключение к БД
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	if name == "help" {
+		printUsage()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
 
-	repo, err := repository.NewPostgresRepository(connStr)
+	// Загрузка конфигурации
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if _, err := logging.Setup(logging.Config{Format: cfg.LogFormat, Level: cfg.LogLevel}); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid log settings: %v\n", err)
+		os.Exit(2)
 	}
-	defer repo.Close()
 
-	// Инициализация сервиса
-	service := service.NewService(repo)
+	// Первый сигнал отменяет контекст и команда завершается штатно (с отложенными Close),
+	// повторный - прерывает процесс сразу
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
 
-	// Инициализация handlers
-	handler := handlers.NewHTTPHandler(service)
+	err = cmd.run(ctx, cfg, args)
+	stop()
+	if err != nil {
+		slog.Error("command failed", "command", name, logging.Err(err))
+		os.Exit(1)
+	}
+}
 
-	// Настройка роутера
-	router := mux.NewRouter()
-	handler.RegisterRoutes(router)
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	// Health check для Docker
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		if err := service.HealthCheck(r.Context()); err != nil {
-			http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags] [args]\n\nCommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for command flags.\n", os.Args[0])
+}
+
+// openRepository подключается к БД по настройкам из конфигурации
+func openRepository(cfg *config.Config) (ports.Repository, error) {
+	return repository.NewPostgresRepository(cfg.DSN(), repository.PoolConfig{
+		MaxOpenConns:    cfg.DBMaxOpenConns,
+		MaxIdleConns:    cfg.DBMaxIdleConns,
+		ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
 	})
+}
+
+// openService подключается к БД и собирает сервис для подкоманд CLI
+func openService(cfg *config.Config) (ports.Repository, ports.Service, error) {
+	repo, err := openRepository(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, newService(cfg, repo), nil
+}
+
+func newService(cfg *config.Config, repo ports.Repository) ports.Service {
+	return service.NewService(repo, serviceSettings(cfg))
+}
+
+// serviceSettings переносит настройки анализа из конфигурации в сервис
+func serviceSettings(cfg *config.Config) service.Settings {
+	return service.Settings{
+		OwnMerchantID:  cfg.OwnMerchantID,
+		BulkWorkers:    cfg.BulkWorkers,
+		JobMaxAttempts: cfg.QueueMaxAttempts,
+		DefaultCityID:  cfg.DefaultCityID,
+		Thresholds:     analysisThresholds(cfg),
+	}
+}
 
-	log.Printf("Server starting on port %s", cfg.ServerPort)
-	log.Fatal(http.ListenAndServe(":"+cfg.ServerPort, router))
+func analysisThresholds(cfg *config.Config) service.Thresholds {
+	return service.Thresholds{
+		DumpingRatio:  cfg.AnalysisDumpingRatio,
+		NoiseScore:    cfg.AnalysisNoiseScore,
+		OutlierFactor: cfg.AnalysisOutlierFactor,
+	}
+}
+
+// setThresholds меняет пороги анализа работающего сервиса
+func setThresholds(svc ports.Service, cfg *config.Config) {
+	if setter, ok := svc.(interface{ SetThresholds(service.Thresholds) }); ok {
+		setter.SetThresholds(analysisThresholds(cfg))
+	}
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"context"
+	"flag"
+	"fmt"
+)
+
+// runMigrate реализует подкоманду `migrate`:
+//
+//	server migrate          применить недостающие миграции
+//	server migrate -status  показать непримененные миграции
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	status := fs.Bool("status", false, "list pending migrations without applying them")
+	fs.Parse(args)
+
+	repo, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	if *status {
+		pending, err := repo.PendingMigrations(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("Schema is up to date")
+			return nil
+		}
+		fmt.Printf("Pending migrations: %v\n", pending)
+		return nil
+	}
+
+	applied, err := repo.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Applied %d migrations\n", applied)
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runPurge реализует подкоманду `purge`:
+//
+//	server purge -days 180 -dry-run
+func runPurge(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	days := fs.Int("days", 0, "delete data older than this many days")
+	dryRun := fs.Bool("dry-run", false, "only count the rows that would be deleted")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	if *days <= 0 {
+		fs.Usage()
+		return fmt.Errorf("-days must be positive")
+	}
+
+	repo, svc, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	result, err := svc.Purge(ctx, time.Now().AddDate(0, 0, -*days), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	verb := "Deleted"
+	if result.DryRun {
+		verb = "Would delete"
+	}
+	fmt.Printf("%s rows older than %s:\n", verb, result.Before.Format(time.DateOnly))
+	fmt.Printf("  price_history       %d\n", result.PriceHistory)
+	fmt.Printf("  product_info        %d\n", result.ProductInfo)
+	fmt.Printf("  quarantined_offers  %d\n", result.QuarantinedOffers)
+	fmt.Printf("  ingestion_jobs      %d\n", result.Jobs)
+	fmt.Printf("  rate_limit_buckets  %d\n", result.RateLimitBuckets)
+	fmt.Printf("  rate_limit_quotas   %d\n", result.RateLimitQuotas)
+	return nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/fetcher"
+	"Mini-Quicko/internal/handlers"
+	"Mini-Quicko/internal/health"
+	"Mini-Quicko/internal/importer"
+	"Mini-Quicko/internal/logging"
+	"Mini-Quicko/internal/metrics"
+	"Mini-Quicko/internal/openapi"
+	"Mini-Quicko/internal/ratelimit"
+	"Mini-Quicko/internal/requestid"
+	"Mini-Quicko/internal/tracing"
+	"Mini-Quicko/internal/worker"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// runServe реализует подкоманду `serve` (запуск без подкоманды):
+//
+//	server serve -port 8081
+//
+// По SIGTERM сервер перестает отвечать готовностью на /readyz, дорабатывает текущие запросы,
+// затем останавливает планировщик и воркеры очереди и закрывает БД.
+func runServe(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := fs.String("port", cfg.ServerPort, "HTTP port (default: server.port)")
+	fs.Parse(args)
+
+	repo, err := openRepository(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer repo.Close()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		Insecure:    cfg.TracingInsecure,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	defer shutdown("tracing", shutdownTracing, cfg.ServerShutdownTimeout)
+
+	// Трассировка и метрики собираются декораторами вокруг репозитория и сервиса
+	tracingEnabled := cfg.TracingExporter != "none"
+	if tracingEnabled {
+		repo = tracing.InstrumentRepository(repo)
+	}
+	if cfg.MetricsEnabled {
+		if postgres, ok := repo.(interface{ DB() *sql.DB }); ok {
+			if err := metrics.RegisterDB(postgres.DB(), cfg.DBName); err != nil {
+				return err
+			}
+		}
+		repo = metrics.InstrumentRepository(repo)
+	}
+	// Пороги меняются у самого сервиса, декораторы их не пропускают
+	analyzer := newService(cfg, repo)
+	service := analyzer
+	if tracingEnabled {
+		service = tracing.InstrumentService(service)
+	}
+	if cfg.MetricsEnabled {
+		service = metrics.InstrumentService(service)
+	}
+
+	if cfg.DBAutoMigrate {
+		if _, err := repo.Migrate(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Воркеры асинхронной загрузки
+	pool := worker.NewPool(repo, service, worker.Config{
+		Workers:      cfg.QueueWorkers,
+		PollInterval: cfg.QueuePollInterval,
+		BaseBackoff:  cfg.QueueBackoff,
+		Lease:        cfg.QueueLease,
+	})
+	if err := pool.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start ingestion workers: %w", err)
+	}
+	defer shutdown("ingestion workers", pool.Shutdown, cfg.ServerShutdownTimeout)
+
+	// Проверки /health; критичны только БД и миграции
+	checks := []health.Check{
+		health.Database(service.HealthCheck),
+		health.Migrations(repo),
+		health.Queue(repo),
+		health.Ingestion(repo),
+	}
+
+	// Загрузка офферов с Kaspi по расписанию
+	if cfg.FetcherEnabled {
+		scheduler := newScheduler(cfg, service)
+		scheduler.Start(ctx)
+		defer shutdown("fetch scheduler", scheduler.Shutdown, cfg.ServerShutdownTimeout)
+		checks = append(checks, health.Scheduler(scheduler.Lag))
+	}
+	checker := health.NewChecker(cfg.HealthTimeout, healthThresholds(cfg), checks...)
+
+	importColumns, err := importer.NewMapping(cfg.ImportColumns)
+	if err != nil {
+		return fmt.Errorf("invalid import.columns: %w", err)
+	}
+
+	limiter, ipLimiter, err := newRateLimiters(cfg, repo)
+	if err != nil {
+		return err
+	}
+	trustedProxies := 0
+	if cfg.RateLimitTrustProxy {
+		trustedProxies = cfg.RateLimitTrustedProxies
+	}
+
+	// Инициализация handlers
+	handlerConfig := handlers.Config{
+		ImportColumns:  importColumns,
+		RateLimiter:    limiter,
+		IPRateLimiter:  ipLimiter,
+		TrustedProxies: trustedProxies,
+		Health:         checker,
+	}
+	handler := handlers.NewHTTPHandler(service, handlerConfig)
+
+	// Настройка роутера
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	// Трассировка и метрики HTTP первыми, чтобы учитывать и отказы авторизации и ограничения частоты
+	if tracingEnabled {
+		router.Use(tracing.Middleware)
+	}
+	if cfg.MetricsEnabled {
+		router.Use(metrics.Middleware)
+	}
+
+	// Ограничение по IP до Authorize: запросы с неверными ключами тоже ограничиваются
+	router.Use(handler.RateLimitIP)
+
+	// Проверка API ключей и ролей идет до проверки запроса по спецификации
+	if cfg.AuthEnabled {
+		router.Use(handler.Authorize)
+	} else {
+		slog.Warn("API key authentication is disabled (auth.enabled: false)")
+	}
+	// Ограничение частоты запросов после Authorize: клиент определяется по API ключу
+	router.Use(handler.RateLimit)
+
+	// Проверка запросов по спецификации OpenAPI
+	spec, err := openapi.Load()
+	if err != nil {
+		return err
+	}
+	for _, route := range spec.Undocumented(router) {
+		slog.Warn("route is missing from the OpenAPI spec", "route", route)
+	}
+	if cfg.OpenAPIValidateRequests || cfg.OpenAPIValidateResponses {
+		validator := openapi.NewValidator(spec, cfg.OpenAPIValidateRequests, cfg.OpenAPIValidateResponses)
+		router.Use(validator.Middleware)
+	}
+
+	// ID запроса и журнал запросов нужны и для ответов mux о неизвестном маршруте,
+	// поэтому эти middleware оборачивают весь роутер
+	var root http.Handler = router
+	if cfg.LogAccess {
+		root = logging.AccessLog(root)
+	}
+
+	server := &http.Server{
+		Addr:              ":" + *port,
+		Handler:           requestid.Middleware(root),
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	// Пороги анализа и /health и уровень логов применяются при сохранении config.yaml,
+	// остальные настройки - после перезапуска
+	if cfg.Watch(func(next *config.Config) { reloadConfig(next, analyzer, checker) }) {
+		slog.Info("watching configuration file", "file", cfg.ConfigFile())
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("server starting", "port", *port)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	// Метрики на отдельном адресе: метки по рабочим пространствам не должны быть видны клиентам API
+	if cfg.MetricsEnabled {
+		metricsServer := newMetricsServer(cfg)
+		go func() {
+			slog.Info("metrics server starting", "addr", cfg.MetricsAddr)
+			serveErr <- metricsServer.ListenAndServe()
+		}()
+		defer shutdown("metrics server", metricsServer.Shutdown, cfg.ServerShutdownTimeout)
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down, draining requests", "delay", cfg.ServerShutdownDelay, "timeout", cfg.ServerShutdownTimeout)
+	handler.Drain()
+	time.Sleep(cfg.ServerShutdownDelay)
+
+	// Планировщик, воркеры и трассировка останавливаются отложенными вызовами после HTTP сервера
+	shutdown("http server", server.Shutdown, cfg.ServerShutdownTimeout)
+	return nil
+}
+
+// newMetricsServer отдает /metrics на metrics.addr, который закрывается от клиентов на уровне сети
+func newMetricsServer(cfg *config.Config) *http.Server {
+	routes := http.NewServeMux()
+	routes.Handle("GET /metrics", metrics.Handler())
+	return &http.Server{
+		Addr:              cfg.MetricsAddr,
+		Handler:           routes,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// shutdown останавливает компонент, ожидая его не дольше timeout
+func shutdown(name string, stop func(context.Context) error, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := stop(ctx); err != nil {
+		slog.Warn("shutdown did not complete", "component", name, logging.Err(err))
+		return
+	}
+	slog.Info("stopped", "component", name)
+}
+
+// reloadConfig применяет настройки, которые меняются без перезапуска
+func reloadConfig(cfg *config.Config, analyzer ports.Service, checker *health.Checker) {
+	if err := logging.SetLevel(cfg.LogLevel); err != nil {
+		slog.Warn("log level was not changed", logging.Err(err))
+	}
+	checker.SetThresholds(healthThresholds(cfg))
+	setThresholds(analyzer, cfg)
+	slog.Info("configuration reloaded", "log_level", cfg.LogLevel,
+		"dumping_ratio", cfg.AnalysisDumpingRatio, "noise_score", cfg.AnalysisNoiseScore, "outlier_factor", cfg.AnalysisOutlierFactor)
+}
+
+func healthThresholds(cfg *config.Config) health.Thresholds {
+	return health.Thresholds{
+		MaxQueueDepth:   cfg.HealthMaxQueueDepth,
+		MaxSchedulerLag: cfg.HealthMaxSchedulerLag,
+		MaxIngestionAge: cfg.HealthMaxIngestionAge,
+	}
+}
+
+// newRateLimiters выбирает хранилище ограничений: память процесса или общую для реплик БД.
+// Возвращает ограничитель по клиенту (API ключу) и ограничитель по IP до проверки ключа.
+// При ratelimit.enabled: false оба nil, при ratelimit.ip_rate: 0 nil только ограничитель по IP.
+func newRateLimiters(cfg *config.Config, repo ports.Repository) (*ratelimit.Limiter, *ratelimit.Limiter, error) {
+	if !cfg.RateLimitEnabled {
+		return nil, nil, nil
+	}
+
+	var store ratelimit.Store
+	switch cfg.RateLimitStore {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store = repo
+	default:
+		return nil, nil, fmt.Errorf("unknown ratelimit.store %q: use memory or postgres", cfg.RateLimitStore)
+	}
+
+	var ipLimiter *ratelimit.Limiter
+	if cfg.RateLimitIP.Rate > 0 {
+		ipLimiter = ratelimit.NewLimiter(store, cfg.RateLimitIP, nil)
+	}
+	return ratelimit.NewLimiter(store, cfg.RateLimitDefault, cfg.RateLimitRoutes), ipLimiter, nil
+}
+
+// newScheduler собирает клиент Kaspi и планировщик опроса по конфигурации
+func newScheduler(cfg *config.Config, service ports.Service) *fetcher.Scheduler {
+	client := fetcher.NewClient(fetcher.ClientConfig{
+		URL:       cfg.FetcherURL,
+		CityID:    cfg.FetcherCityID,
+		PageSize:  cfg.FetcherPageSize,
+		MaxPages:  cfg.FetcherMaxPages,
+		UserAgent: cfg.FetcherUserAgent,
+		Timeout:   cfg.FetcherTimeout,
+	})
+
+	source := fetcher.MultiSource{fetcher.StaticSource{ProductIDs: cfg.FetcherProducts, Cities: cfg.FetcherCities}}
+	if cfg.FetcherWatchlist {
+		source = append(source, fetcher.WatchlistSource{Service: service, Tags: cfg.FetcherTags, DefaultCities: cfg.FetcherCities})
+	}
+
+	return fetcher.NewScheduler(client, service, source, fetcher.SchedulerConfig{
+		Interval:      cfg.FetcherInterval,
+		Jitter:        cfg.FetcherJitter,
+		RatePerSecond: cfg.FetcherRate,
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"Mini-Quicko/config"
+	"Mini-Quicko/internal/core/models"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runWorkspaces реализует подкоманду `workspaces` - управление рабочими пространствами команд:
+//
+//	server workspaces create -id team-a -name "Команда A" -merchant 30012345
+//	server workspaces update -id team-a -name "Команда A" -merchant 30054321
+//	server workspaces list
+func runWorkspaces(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: workspaces create|update|list")
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("workspaces "+action, flag.ExitOnError)
+	id := fs.String("id", "", "workspace ID: lowercase letters, digits, '-' and '_' (create, update)")
+	name := fs.String("name", "", "display name (create, update)")
+	merchantID := fs.String("merchant", "", "own merchant ID of the team, excluded from competitors (create, update)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	repo, svc, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	switch action {
+	case "create", "update":
+		workspace := &models.Workspace{ID: *id, Name: *name, OwnMerchantID: *merchantID}
+		if action == "create" {
+			err = svc.CreateWorkspace(ctx, workspace)
+		} else {
+			err = svc.UpdateWorkspace(ctx, workspace)
+		}
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(workspace)
+		}
+		fmt.Printf("Saved workspace %s (%s)\n", workspace.ID, workspace.Name)
+		return nil
+
+	case "list":
+		workspaces, err := svc.ListWorkspaces(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(workspaces)
+		}
+
+		table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tNAME\tMERCHANT\tCREATED")
+		for _, workspace := range workspaces {
+			merchant := workspace.OwnMerchantID
+			if merchant == "" {
+				merchant = "-"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", workspace.ID, workspace.Name, merchant, workspace.CreatedAt.Format(time.DateTime))
+		}
+		return table.Flush()
+
+	default:
+		return fmt.Errorf("unknown workspaces action %q: use create, update or list", action)
+	}
+}
@@ -1,57 +1,536 @@
+// Package config загружает настройки из config.yaml и переменных окружения.
+//
+// Переменная окружения переопределяет ключ файла: имя ключа в верхнем регистре с "_" вместо
+// точки (db.host -> DB_HOST, fetcher.max_pages -> FETCHER_MAX_PAGES). Секреты можно передать
+// файлом: db.password_file (DB_PASSWORD_FILE) и db.dsn_file (DB_DSN_FILE), например Docker secrets.
+// Списки в переменных окружения перечисляются через запятую; карты (ratelimit.routes,
+// import.columns) задаются только в файле.
+//
+// Значение по умолчанию действует только для незаданного (или пустого) ключа: явный 0 сохраняется
+// как есть, а Validate отклоняет его там, где ноль не имеет смысла.
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"Mini-Quicko/internal/logging"
+	"Mini-Quicko/internal/ratelimit"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
 // Структура для хранения конфигурации
 type Config struct {
 	ServerPort string
+
+	// Таймауты HTTP сервера и остановки по SIGTERM
+	ServerReadHeaderTimeout time.Duration
+	ServerReadTimeout       time.Duration
+	ServerWriteTimeout      time.Duration
+	ServerIdleTimeout       time.Duration
+	ServerShutdownDelay     time.Duration // пауза между сигналом и закрытием порта, пока балансировщик видит 503 на /readyz
+	ServerShutdownTimeout   time.Duration // срок на завершение запросов, задач очереди и сохранения загруженных офферов
+
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
 	DBName     string
+	DBSSLMode  string // disable, require, verify-ca или verify-full
+	DBDSN      string // готовая строка подключения, заменяет поля выше
+
+	// Пул соединений с БД
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+
+	// Применять миграции схемы при запуске сервера
+	DBAutoMigrate bool
+
+	// Настройки анализа
+	OwnMerchantID string
+	DefaultCityID string
+	BulkWorkers   int
+
+	// Пороги анализа (меняются без перезапуска)
+	AnalysisDumpingRatio  float64 // цена ниже средней по сегменту в этой доле - демпинг
+	AnalysisNoiseScore    float64 // продавцы с репутацией ниже - шум
+	AnalysisOutlierFactor float64 // цена дальше медианы в столько раз - карантин
+
+	// Логи: формат text или json, уровень debug/info/warn/error и журнал HTTP запросов
+	LogFormat string
+	LogLevel  string
+	LogAccess bool
+
+	// Проверка API ключей и ролей на маршрутах
+	AuthEnabled bool
+
+	// Метрики Prometheus на /metrics отдельного адреса, недоступного клиентам API
+	MetricsEnabled bool
+	MetricsAddr    string
+
+	// Пороги /health: выход за порог переводит компонент в degraded
+	HealthTimeout         time.Duration
+	HealthMaxQueueDepth   int
+	HealthMaxSchedulerLag time.Duration
+	HealthMaxIngestionAge time.Duration
+
+	// Трассировка OpenTelemetry
+	TracingExporter    string // none, otlp или stdout
+	TracingEndpoint    string
+	TracingInsecure    bool
+	TracingServiceName string
+	TracingSampleRatio float64
+
+	// Ограничение частоты запросов и суточные квоты по API ключу или IP
+	RateLimitEnabled        bool
+	RateLimitStore          string // memory или postgres
+	RateLimitTrustProxy     bool
+	RateLimitTrustedProxies int // число своих прокси, добавляющих адрес в X-Forwarded-For
+	RateLimitDefault        ratelimit.Limit
+	RateLimitRoutes         map[string]ratelimit.Limit
+	RateLimitIP             ratelimit.Limit // по IP до проверки API ключа, Rate 0 - без ограничения
+
+	// Проверка запросов и ответов по спецификации OpenAPI
+	OpenAPIValidateRequests  bool
+	OpenAPIValidateResponses bool
+
+	// Сопоставление полей оффера колонкам CSV/XLSX при импорте: поле -> заголовок
+	ImportColumns map[string]string
+
+	// Очередь асинхронной загрузки
+	QueueWorkers      int
+	QueuePollInterval time.Duration
+	QueueMaxAttempts  int
+	QueueBackoff      time.Duration
+	QueueLease        time.Duration
+
+	// Загрузка офферов с Kaspi по расписанию
+	FetcherEnabled   bool
+	FetcherURL       string
+	FetcherCityID    string
+	FetcherPageSize  int
+	FetcherMaxPages  int
+	FetcherUserAgent string
+	FetcherTimeout   time.Duration
+	FetcherInterval  time.Duration
+	FetcherJitter    float64
+	FetcherRate      float64
+	FetcherProducts  []string
+	FetcherCities    []string
+	FetcherWatchlist bool
+	FetcherTags      []string
+
+	// viper с прочитанным файлом, нужен Watch
+	v *viper.Viper
 }
 
-// Функция для загрузки конфигурации
-func Load() *Config {
-	viper.SetConfigName("config")
-	viper.AddConfigPath(".")
-	viper.AddConfigPath("./cmd/server")
-	viper.AddConfigPath("/app")
-	viper.SetConfigType("yaml")
+// Функция для загрузки конфигурации. Возвращает все найденные ошибки сразу: некорректные
+// значения, недопустимые сочетания настроек и ошибки чтения config.yaml (отсутствие файла -
+// не ошибка, тогда действуют значения по умолчанию и переменные окружения).
+func Load() (*Config, error) {
+	v := newViper()
+	v.SetConfigName("config")
+	v.AddConfigPath(".")
+	v.AddConfigPath("./cmd/server")
+	v.AddConfigPath("/app")
 
 	// Чтение конфигурации
-	viper.ReadInConfig()
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	return parse(v)
+}
+
+// newViper настраивает чтение YAML и переменных окружения: db.host -> DB_HOST
+func newViper() *viper.Viper {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	return v
+}
+
+// Функция для разбора и проверки настроек из viper
+func parse(v *viper.Viper) (*Config, error) {
+	l := &loader{v: v}
+
+	cfg := &Config{
+		ServerPort: l.string("server.port", "8080"),
+
+		ServerReadHeaderTimeout: l.duration("server.read_header_timeout", 5*time.Second),
+		ServerReadTimeout:       l.duration("server.read_timeout", 2*time.Minute),
+		ServerWriteTimeout:      l.duration("server.write_timeout", 2*time.Minute),
+		ServerIdleTimeout:       l.duration("server.idle_timeout", 2*time.Minute),
+		ServerShutdownDelay:     l.duration("server.shutdown_delay", 0),
+		ServerShutdownTimeout:   l.duration("server.shutdown_timeout", 30*time.Second),
+
+		DBHost:     l.string("db.host", "db"),
+		DBPort:     l.string("db.port", "5432"),
+		DBUser:     l.string("db.user", "postgres"),
+		DBPassword: l.secret("db.password", "password"),
+		DBName:     l.string("db.name", "kaspi_analyzer"),
+		DBSSLMode:  l.string("db.sslmode", "disable"),
+		DBDSN:      l.secret("db.dsn", ""),
 
-	// Переменные окружения
-	viper.AutomaticEnv() // Автоматическое считывание переменных окружения
+		DBMaxOpenConns:    l.int("db.max_open_conns", 25),
+		DBMaxIdleConns:    l.int("db.max_idle_conns", 5),
+		DBConnMaxLifetime: l.duration("db.conn_max_lifetime", 30*time.Minute),
+		DBConnMaxIdleTime: l.duration("db.conn_max_idle_time", 5*time.Minute),
 
-	return &Config{
-		ServerPort: getConfigValue("server.port", "8080"),
-		DBHost:     getConfigValue("db.host", "db"),
-		DBPort:     getConfigValue("db.port", "5432"),
-		DBUser:     getConfigValue("db.user", "postgres"),
-		DBPassword: getConfigValue("db.password", "password"),
-		DBName:     getConfigValue("db.name", "kaspi_analyzer"),
+		DBAutoMigrate: l.bool("db.auto_migrate", true),
+
+		OwnMerchantID: l.string("analysis.own_merchant_id", ""),
+		DefaultCityID: l.string("analysis.default_city_id", "750000000"),
+		BulkWorkers:   l.int("analysis.bulk_workers", 8),
+
+		AnalysisDumpingRatio:  l.float("analysis.dumping_ratio", 0.9),
+		AnalysisNoiseScore:    l.float("analysis.noise_score", 30),
+		AnalysisOutlierFactor: l.float("analysis.outlier_factor", 10),
+
+		LogFormat: l.string("log.format", "text"),
+		LogLevel:  l.string("log.level", "info"),
+		LogAccess: l.bool("log.access", true),
+
+		AuthEnabled: l.bool("auth.enabled", true),
+
+		MetricsEnabled: l.bool("metrics.enabled", true),
+		MetricsAddr:    l.string("metrics.addr", ":9090"),
+
+		HealthTimeout:         l.duration("health.timeout", 2*time.Second),
+		HealthMaxQueueDepth:   l.int("health.max_queue_depth", 1000),
+		HealthMaxSchedulerLag: l.duration("health.max_scheduler_lag", 10*time.Minute),
+		HealthMaxIngestionAge: l.duration("health.max_ingestion_age", 6*time.Hour),
+
+		TracingExporter:    l.string("tracing.exporter", "none"),
+		TracingEndpoint:    l.string("tracing.endpoint", "localhost:4318"),
+		TracingInsecure:    l.bool("tracing.insecure", true),
+		TracingServiceName: l.string("tracing.service_name", "mini-quicko"),
+		TracingSampleRatio: l.float("tracing.sample_ratio", 1),
+
+		RateLimitEnabled:        l.bool("ratelimit.enabled", true),
+		RateLimitStore:          l.string("ratelimit.store", "memory"),
+		RateLimitTrustProxy:     l.bool("ratelimit.trust_proxy", false),
+		RateLimitTrustedProxies: l.int("ratelimit.trusted_proxies", 1),
+		RateLimitDefault: ratelimit.Limit{
+			Rate:  l.float("ratelimit.rate", 20),
+			Burst: l.int("ratelimit.burst", 40),
+			Daily: l.int("ratelimit.daily", 0),
+		},
+		RateLimitRoutes: l.rateLimitRoutes("ratelimit.routes"),
+		RateLimitIP: ratelimit.Limit{
+			Rate:  l.float("ratelimit.ip_rate", 50),
+			Burst: l.int("ratelimit.ip_burst", 100),
+		},
+
+		OpenAPIValidateRequests:  l.bool("openapi.validate_requests", true),
+		OpenAPIValidateResponses: l.bool("openapi.validate_responses", false),
+
+		ImportColumns: v.GetStringMapString("import.columns"),
+
+		QueueWorkers:      l.int("queue.workers", 4),
+		QueuePollInterval: l.duration("queue.poll_interval", time.Second),
+		QueueMaxAttempts:  l.int("queue.max_attempts", 5),
+		QueueBackoff:      l.duration("queue.backoff", 2*time.Second),
+		QueueLease:        l.duration("queue.lease", 2*time.Minute),
+
+		FetcherEnabled:   l.bool("fetcher.enabled", false),
+		FetcherURL:       l.string("fetcher.url", "https://kaspi.kz/yml/offer-view/offers/{productId}"),
+		FetcherCityID:    l.string("fetcher.city_id", "750000000"),
+		FetcherPageSize:  l.int("fetcher.page_size", 64),
+		FetcherMaxPages:  l.int("fetcher.max_pages", 10),
+		FetcherUserAgent: l.string("fetcher.user_agent", "Mozilla/5.0 (compatible; Mini-Quicko/1.0)"),
+		FetcherTimeout:   l.duration("fetcher.timeout", 15*time.Second),
+		FetcherInterval:  l.duration("fetcher.interval", 30*time.Minute),
+		FetcherJitter:    l.float("fetcher.jitter", 0.1),
+		FetcherRate:      l.float("fetcher.rate", 1),
+		FetcherProducts:  l.strings("fetcher.products"),
+		FetcherCities:    l.strings("fetcher.cities"),
+		FetcherWatchlist: l.bool("fetcher.watchlist", true),
+		FetcherTags:      l.strings("fetcher.tags"),
+
+		v: v,
 	}
+
+	if err := errors.Join(append(l.errs, cfg.Validate())...); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
 }
 
-// Функция для получения значения из конфигурации с возможностью использования переменных окружения
-func getConfigValue(key, defaultValue string) string {
-	// Пытаемся получить значение из конфигурации
-	if value := viper.GetString(key); value != "" {
-		return value
+// Validate проверяет допустимые значения и сочетания настроек
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.ServerPort), "server.port: %q is not a valid port", c.ServerPort)
+	if c.DBDSN == "" {
+		check(c.DBHost != "", "db.host: must not be empty")
+		check(validPort(c.DBPort), "db.port: %q is not a valid port", c.DBPort)
+		check(c.DBName != "", "db.name: must not be empty")
+		check(oneOf(c.DBSSLMode, "disable", "require", "verify-ca", "verify-full"),
+			"db.sslmode: unknown mode %q: use disable, require, verify-ca or verify-full", c.DBSSLMode)
+	}
+	// db.max_open_conns: 0 - без ограничения, как в database/sql
+	check(c.DBMaxOpenConns == 0 || c.DBMaxIdleConns <= c.DBMaxOpenConns,
+		"db.max_idle_conns: %d exceeds db.max_open_conns %d", c.DBMaxIdleConns, c.DBMaxOpenConns)
+	check(c.ServerShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+	check(c.BulkWorkers > 0, "analysis.bulk_workers: must be positive")
+	check(c.HealthTimeout > 0, "health.timeout: must be positive")
+
+	check(c.AnalysisDumpingRatio > 0 && c.AnalysisDumpingRatio <= 1,
+		"analysis.dumping_ratio: %v must be in (0, 1]", c.AnalysisDumpingRatio)
+	check(c.AnalysisNoiseScore > 0 && c.AnalysisNoiseScore <= 100, "analysis.noise_score: %v must be in (0, 100]", c.AnalysisNoiseScore)
+	check(c.AnalysisOutlierFactor > 1, "analysis.outlier_factor: %v must be greater than 1", c.AnalysisOutlierFactor)
+
+	check(oneOf(strings.ToLower(c.LogFormat), "text", "json"), "log.format: unknown format %q: use text or json", c.LogFormat)
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
 	}
 
-	// Если в конфиге нет значения, пытаемся считать из переменной окружения
-	if value := os.Getenv(key); value != "" {
+	check(oneOf(c.TracingExporter, "none", "otlp", "stdout"),
+		"tracing.exporter: unknown exporter %q: use none, otlp or stdout", c.TracingExporter)
+	check(c.TracingSampleRatio <= 1, "tracing.sample_ratio: %v must be in [0, 1]", c.TracingSampleRatio)
+
+	check(oneOf(c.RateLimitStore, "memory", "postgres"),
+		"ratelimit.store: unknown store %q: use memory or postgres", c.RateLimitStore)
+
+	check(c.QueueWorkers > 0, "queue.workers: must be positive")
+	check(c.QueuePollInterval > 0, "queue.poll_interval: must be positive")
+	check(c.QueueMaxAttempts > 0, "queue.max_attempts: must be positive")
+	check(c.QueueBackoff > 0, "queue.backoff: must be positive")
+	check(c.QueueLease > 0, "queue.lease: must be positive")
+
+	check(c.FetcherJitter <= 1, "fetcher.jitter: %v must be in [0, 1]", c.FetcherJitter)
+	if c.FetcherEnabled {
+		check(c.FetcherURL != "", "fetcher.url: must not be empty when the fetcher is enabled")
+		check(c.FetcherPageSize > 0, "fetcher.page_size: must be positive when the fetcher is enabled")
+		check(c.FetcherMaxPages > 0, "fetcher.max_pages: must be positive when the fetcher is enabled")
+		check(c.FetcherTimeout > 0, "fetcher.timeout: must be positive when the fetcher is enabled")
+		check(c.FetcherInterval > 0, "fetcher.interval: must be positive when the fetcher is enabled")
+		check(c.FetcherRate > 0, "fetcher.rate: must be positive when the fetcher is enabled")
+	}
+
+	return errors.Join(errs...)
+}
+
+// DSN возвращает строку подключения к PostgreSQL: db.dsn, если задана, иначе собранную из полей
+func (c *Config) DSN() string {
+	if c.DBDSN != "" {
+		return c.DBDSN
+	}
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSN(c.DBHost), quoteDSN(c.DBPort), quoteDSN(c.DBUser), quoteDSN(c.DBPassword), quoteDSN(c.DBName), quoteDSN(c.DBSSLMode))
+}
+
+// Watch следит за файлом конфигурации и при изменении вызывает onChange с новыми настройками.
+// Настройки с ошибками пропускаются с предупреждением в логе, действуют прежние.
+// Возвращает false, если сервер запущен без файла конфигурации.
+//
+// Применять без перезапуска стоит только настройки, не задающие структуру сервера
+// (пороги, уровень логов): порт, БД, воркеры и маршруты читаются один раз при запуске.
+func (c *Config) Watch(onChange func(next *Config)) bool {
+	if c.v == nil || c.v.ConfigFileUsed() == "" {
+		return false
+	}
+	seen, _ := os.ReadFile(c.v.ConfigFileUsed())
+	c.v.OnConfigChange(func(event fsnotify.Event) {
+		// Редакторы сохраняют файл в несколько записей (усечение, затем запись) - ждем последнюю
+		// и перечитываем сами: viper только пишет ошибку чтения в свой лог
+		time.Sleep(reloadDelay)
+		data, err := os.ReadFile(c.v.ConfigFileUsed())
+		if err == nil && bytes.Equal(data, seen) {
+			return
+		}
+		seen = data
+		if err == nil {
+			err = c.v.ReadInConfig()
+		}
+		var next *Config
+		if err == nil {
+			next, err = parse(c.v)
+		}
+		if err != nil {
+			slog.Warn("configuration change rejected", "file", event.Name, logging.Err(err))
+			return
+		}
+		onChange(next)
+	})
+	c.v.WatchConfig()
+	return true
+}
+
+// reloadDelay - пауза между изменением файла и его чтением
+const reloadDelay = 100 * time.Millisecond
+
+// ConfigFile возвращает путь к прочитанному файлу конфигурации или пустую строку
+func (c *Config) ConfigFile() string {
+	if c.v == nil {
+		return ""
+	}
+	return c.v.ConfigFileUsed()
+}
+
+// quoteDSN экранирует значение для строки подключения в формате key=value
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
 		return value
 	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+func validPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port > 0 && port <= 65535
+}
 
-	// Возвращаем значение по умолчанию
+func oneOf(value string, allowed ...string) bool {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// loader читает значения из viper и собирает ошибки разбора, чтобы сообщить обо всех сразу.
+// Незаданное или пустое значение заменяется значением по умолчанию, отрицательное - ошибка.
+type loader struct {
+	v    *viper.Viper
+	errs []error
+}
+
+func (l *loader) fail(key, value, expected string) {
+	l.errs = append(l.errs, fmt.Errorf("%s (%s): %q is not %s", key, envName(key), value, expected))
+}
+
+// Функция для получения строкового значения из файла или переменной окружения
+func (l *loader) string(key, defaultValue string) string {
+	if value := strings.TrimSpace(l.v.GetString(key)); value != "" {
+		return value
+	}
 	return defaultValue
 }
+
+// Функция для получения секрета: из файла <key>_file (Docker/Kubernetes secrets) или из самого ключа
+func (l *loader) secret(key, defaultValue string) string {
+	path := l.string(key+"_file", "")
+	if path == "" {
+		return l.string(key, defaultValue)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s_file (%s): %w", key, envName(key+"_file"), err))
+		return ""
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
+
+// Функция для получения неотрицательного целого значения
+func (l *loader) int(key string, defaultValue int) int {
+	raw := l.string(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		l.fail(key, raw, "a non-negative integer")
+		return defaultValue
+	}
+	return value
+}
+
+// Функция для получения длительности в формате time.ParseDuration ("500ms", "2s", "1m")
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	raw := l.string(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		l.fail(key, raw, `a non-negative duration such as "500ms" or "2m"`)
+		return defaultValue
+	}
+	return value
+}
+
+// Функция для получения неотрицательного дробного значения; ноль допустим
+func (l *loader) float(key string, defaultValue float64) float64 {
+	raw := l.string(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		l.fail(key, raw, "a non-negative number")
+		return defaultValue
+	}
+	return value
+}
+
+// Функция для получения логического значения
+func (l *loader) bool(key string, defaultValue bool) bool {
+	raw := l.string(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.fail(key, raw, "a boolean")
+		return defaultValue
+	}
+	return value
+}
+
+// Функция для получения списка: массив в файле или значения через запятую в переменной окружения
+func (l *loader) strings(key string) []string {
+	var values []string
+	switch raw := l.v.Get(key).(type) {
+	case string:
+		values = strings.Split(raw, ",")
+	default:
+		values = l.v.GetStringSlice(key)
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// Функция для получения ограничений маршрутов; ключ - "METHOD /path", значение - rate, burst, daily
+func (l *loader) rateLimitRoutes(key string) map[string]ratelimit.Limit {
+	routes := make(map[string]ratelimit.Limit)
+	if err := l.v.UnmarshalKey(key, &routes); err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %w", key, err))
+		return map[string]ratelimit.Limit{}
+	}
+	return routes
+}
+
+// envName возвращает имя переменной окружения для ключа: db.host -> DB_HOST
+func envName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
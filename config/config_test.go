@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseYAML разбирает настройки из YAML и переменных окружения так же, как Load
+func parseYAML(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+
+	v := newViper()
+	if err := v.ReadConfig(strings.NewReader(yaml)); err != nil {
+		t.Fatalf("read yaml: %v", err)
+	}
+	return parse(v)
+}
+
+func TestDefaults(t *testing.T) {
+	cfg, err := parseYAML(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBHost != "db" || cfg.DBMaxIdleConns != 5 || cfg.ServerShutdownTimeout != 30*time.Second || cfg.RateLimitIP.Burst != 100 {
+		t.Errorf("defaults: db.host %q, max_idle_conns %d, shutdown_timeout %v, ip_burst %d",
+			cfg.DBHost, cfg.DBMaxIdleConns, cfg.ServerShutdownTimeout, cfg.RateLimitIP.Burst)
+	}
+}
+
+func TestEnvOverridesFile(t *testing.T) {
+	t.Setenv("DB_HOST", "pg.internal")
+	t.Setenv("FETCHER_MAX_PAGES", "3")
+	t.Setenv("FETCHER_PRODUCTS", "101, 102,,103")
+
+	cfg, err := parseYAML(t, "db:\n  host: localhost\n  name: prices\nfetcher:\n  max_pages: 20\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBHost != "pg.internal" {
+		t.Errorf("db.host = %q, want DB_HOST", cfg.DBHost)
+	}
+	if cfg.DBName != "prices" {
+		t.Errorf("db.name = %q, want the file value", cfg.DBName)
+	}
+	if cfg.FetcherMaxPages != 3 {
+		t.Errorf("fetcher.max_pages = %d, want FETCHER_MAX_PAGES", cfg.FetcherMaxPages)
+	}
+	if got := strings.Join(cfg.FetcherProducts, ","); got != "101,102,103" {
+		t.Errorf("fetcher.products = %v", cfg.FetcherProducts)
+	}
+}
+
+func TestExplicitZeroIsKept(t *testing.T) {
+	t.Setenv("RATELIMIT_IP_BURST", "0")
+
+	cfg, err := parseYAML(t, "server:\n  shutdown_delay: 0s\ndb:\n  max_idle_conns: 0\n  max_open_conns: 0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBMaxIdleConns != 0 || cfg.DBMaxOpenConns != 0 {
+		t.Errorf("db.max_idle_conns %d, db.max_open_conns %d; want 0", cfg.DBMaxIdleConns, cfg.DBMaxOpenConns)
+	}
+	if cfg.RateLimitIP.Burst != 0 {
+		t.Errorf("ratelimit.ip_burst = %d, want 0", cfg.RateLimitIP.Burst)
+	}
+	if cfg.ServerShutdownDelay != 0 {
+		t.Errorf("server.shutdown_delay = %v, want 0", cfg.ServerShutdownDelay)
+	}
+}
+
+func TestSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db_password")
+	if err := os.WriteFile(path, []byte("s3cret pass\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_PASSWORD", "from-env")
+	t.Setenv("DB_PASSWORD_FILE", path)
+
+	cfg, err := parseYAML(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBPassword != "s3cret pass" {
+		t.Errorf("db.password = %q, want the file contents without the newline", cfg.DBPassword)
+	}
+	if want := "password='s3cret pass'"; !strings.Contains(cfg.DSN(), want) {
+		t.Errorf("DSN %q does not contain %s", cfg.DSN(), want)
+	}
+
+	t.Setenv("DB_DSN_FILE", filepath.Join(dir, "missing"))
+	if _, err := parseYAML(t, ""); err == nil || !strings.Contains(err.Error(), "db.dsn_file (DB_DSN_FILE)") {
+		t.Errorf("missing secret file: error %v", err)
+	}
+}
+
+func TestQuoteDSN(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "postgres", want: "postgres"},
+		{value: "", want: "''"},
+		{value: "two words", want: "'two words'"},
+		{value: "it's", want: `'it\'s'`},
+		{value: `back\slash`, want: `'back\\slash'`},
+	}
+	for _, tt := range tests {
+		if got := quoteDSN(tt.value); got != tt.want {
+			t.Errorf("quoteDSN(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidationErrorsAreAggregated(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	t.Setenv("QUEUE_WORKERS", "0")
+
+	_, err := parseYAML(t, `
+server:
+  port: "99999"
+db:
+  sslmode: sometimes
+  max_idle_conns: 30
+analysis:
+  dumping_ratio: 1.5
+log:
+  format: xml
+`)
+	if err == nil {
+		t.Fatal("invalid configuration accepted")
+	}
+	for _, want := range []string{
+		`db.max_open_conns (DB_MAX_OPEN_CONNS): "many" is not a non-negative integer`,
+		`server.port: "99999" is not a valid port`,
+		`db.sslmode: unknown mode "sometimes"`,
+		`db.max_idle_conns: 30 exceeds db.max_open_conns 25`,
+		`analysis.dumping_ratio: 1.5 must be in (0, 1]`,
+		`log.format: unknown format "xml"`,
+		`queue.workers: must be positive`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
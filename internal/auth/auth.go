@@ -0,0 +1,57 @@
+// Package auth создает API ключи и передает аутентифицированный ключ через контекст запроса.
+//
+// Ключ имеет вид mq_<prefix>_<secret>. Префикс хранится открыто и показывается в списке ключей,
+// чтобы их можно было различать, а в БД вместо ключа хранится его SHA-256 хэш: ключ случайный
+// и длинный, поэтому медленный хэш (bcrypt) не нужен и поиск по хэшу остается точным.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+const (
+	keyPrefix    = "mq_"
+	prefixBytes  = 4
+	secretBytes  = 24
+	prefixLength = len(keyPrefix) + 2*prefixBytes
+)
+
+// GenerateKey создает новый ключ и возвращает его вместе с отображаемым префиксом
+func GenerateKey() (key, prefix string, err error) {
+	buf := make([]byte, prefixBytes+secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	prefix = keyPrefix + hex.EncodeToString(buf[:prefixBytes])
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(buf[prefixBytes:]), prefix, nil
+}
+
+// HashKey - хэш ключа для хранения и поиска в БД
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// WellFormed отсекает заведомо чужие строки, не обращаясь к БД
+func WellFormed(key string) bool {
+	return strings.HasPrefix(key, keyPrefix) && len(key) > prefixLength+1 && key[prefixLength] == '_'
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, key *models.APIKey) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext возвращает ключ, с которым пришел запрос, или nil
+func FromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(contextKey{}).(*models.APIKey)
+	return key
+}
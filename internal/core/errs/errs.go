@@ -0,0 +1,96 @@
+// Package errs описывает ошибки предметной области. Сервис возвращает их вместо
+// текстовых ошибок, а обработчики по виду ошибки выбирают HTTP статус и код ответа.
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Kind - вид ошибки, он же код ошибки в ответе API
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"          // непредвиденная ошибка, детали только в логе
+	KindNotFound     Kind = "not_found"         // запрошенных данных нет
+	KindValidation   Kind = "validation_failed" // некорректный запрос
+	KindConflict     Kind = "conflict"          // запрос противоречит текущему состоянию
+	KindUnavailable  Kind = "unavailable"       // зависимость (БД) временно недоступна
+	KindUnauthorized Kind = "unauthorized"      // нет API ключа или ключ недействителен
+	KindForbidden    Kind = "forbidden"         // у ключа нет нужной роли
+)
+
+// Error - ошибка предметной области. Message можно показывать клиенту,
+// исходная ошибка Err (например, от БД) попадает только в лог.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(format string, args ...interface{}) error {
+	return &Error{Kind: KindNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func Validation(format string, args ...interface{}) error {
+	return &Error{Kind: KindValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...interface{}) error {
+	return &Error{Kind: KindConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func Unauthorized(format string, args ...interface{}) error {
+	return &Error{Kind: KindUnauthorized, Message: fmt.Sprintf(format, args...)}
+}
+
+func Forbidden(format string, args ...interface{}) error {
+	return &Error{Kind: KindForbidden, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unavailable оборачивает ошибку зависимости, которую имеет смысл повторить позже
+func Unavailable(err error, format string, args ...interface{}) error {
+	return &Error{Kind: KindUnavailable, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// KindOf возвращает вид ошибки. Истекший таймаут считается недоступностью,
+// все прочие нетипизированные ошибки - внутренними.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindUnavailable
+	}
+	return KindInternal
+}
+
+// Is сообщает, относится ли ошибка к виду kind
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// Message возвращает текст ошибки, который безопасно показать клиенту
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "Request timed out"
+	}
+	return "Internal server error"
+}
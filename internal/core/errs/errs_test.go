@@ -0,0 +1,81 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	driverErr := errors.New("pq: duplicate key value violates unique constraint")
+
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{name: "not found", err: NotFound("product %s not found", "p1"), want: KindNotFound},
+		{name: "validation", err: Validation("invalid price"), want: KindValidation},
+		{name: "conflict", err: Conflict("job is running"), want: KindConflict},
+		{name: "unauthorized", err: Unauthorized("invalid API key"), want: KindUnauthorized},
+		{name: "forbidden", err: Forbidden("admin role required"), want: KindForbidden},
+		{name: "unavailable", err: Unavailable(driverErr, "Storage is temporarily unavailable"), want: KindUnavailable},
+		{name: "wrapped", err: fmt.Errorf("analyze: %w", NotFound("no data")), want: KindNotFound},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), want: KindUnavailable},
+		{name: "untyped", err: driverErr, want: KindInternal},
+		{name: "canceled", err: context.Canceled, want: KindInternal},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.err); got != tt.want {
+			t.Errorf("%s: KindOf = %q, want %q", tt.name, got, tt.want)
+		}
+		if !Is(tt.err, tt.want) {
+			t.Errorf("%s: Is(err, %q) = false", tt.name, tt.want)
+		}
+	}
+
+	if Is(nil, KindInternal) {
+		t.Error("Is(nil, internal) = true")
+	}
+	if Is(NotFound("no data"), KindValidation) {
+		t.Error("not found error reported as validation")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	driverErr := errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "typed", err: Validation("price %d must be positive", -1), want: "price -1 must be positive"},
+		{name: "wrapped typed", err: fmt.Errorf("save: %w", Conflict("job is running")), want: "job is running"},
+		{name: "cause is not shown", err: Unavailable(driverErr, "Storage is temporarily unavailable"), want: "Storage is temporarily unavailable"},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), want: "Request timed out"},
+		{name: "untyped", err: fmt.Errorf("failed to save: %w", driverErr), want: "Internal server error"},
+	}
+	for _, tt := range tests {
+		if got := Message(tt.err); got != tt.want {
+			t.Errorf("%s: Message = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestErrorKeepsCause(t *testing.T) {
+	cause := errors.New("connection reset by peer")
+	err := Unavailable(cause, "Storage is temporarily unavailable")
+
+	// Для лога текст ошибки содержит причину, а errors.Is доходит до нее
+	if got, want := err.Error(), "Storage is temporarily unavailable: connection reset by peer"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is does not reach the cause")
+	}
+	if got := NotFound("no data").Error(); got != "no data" {
+		t.Errorf("Error() without cause = %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Role - право доступа API ключа. Admin включает все остальные роли.
+type Role string
+
+const (
+	RoleIngest Role = "ingest" // загрузка данных (скрейпер)
+	RoleRead   Role = "read"   // чтение анализа, истории и списков (дашборды)
+	RoleAdmin  Role = "admin"  // изменение списка отслеживания и управление ключами
+)
+
+// Roles - все роли в порядке возрастания прав
+var Roles = []Role{RoleIngest, RoleRead, RoleAdmin}
+
+type APIKey struct {
+	ID          int64      `json:"id"`
+	WorkspaceID string     `json:"workspace_id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Hash        string     `json:"-"`
+	Roles       []Role     `json:"roles"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasRole сообщает, дает ли ключ хотя бы одну из ролей
+func (k *APIKey) HasRole(roles ...Role) bool {
+	for _, have := range k.Roles {
+		if have == RoleAdmin {
+			return true
+		}
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type CreateAPIKeyRequest struct {
+	Name  string `json:"name"`
+	Roles []Role `json:"roles"`
+}
+
+// NewAPIKey - созданный ключ. Сам ключ показывается только один раз, в БД хранится его хэш.
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type BacktestRequest struct {
+	ProductID  string    `json:"product_id"`
+	CityID     string    `json:"city_id"`
+	Strategies []string  `json:"strategies"`
+	MerchantID string    `json:"merchant_id"`
+	UnitCost   float64   `json:"unit_cost"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+}
+
+type BacktestReport struct {
+	ProductID string           `json:"product_id"`
+	CityID    string           `json:"city_id"`
+	Snapshots int              `json:"snapshots"`
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Results   []StrategyResult `json:"results"`
+}
+
+type StrategyResult struct {
+	Strategy           string  `json:"strategy"`
+	CheapestRate       float64 `json:"cheapest_rate"`
+	AvgPrice           float64 `json:"avg_price"`
+	AvgPremiumPercent  float64 `json:"avg_premium_percent"`
+	AvgMarginPercent   float64 `json:"avg_margin_percent"`
+	PriceChanges       int     `json:"price_changes"`
+	EvaluatedSnapshots int     `json:"evaluated_snapshots"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+type BulkAnalysisRequest struct {
+	ProductIDs []string       `json:"product_ids"`
+	Filter     *ProductFilter `json:"filter,omitempty"`
+	MerchantID string         `json:"merchant_id"`
+	CityID     string         `json:"city_id"`
+}
+
+type ProductFilter struct {
+	UpdatedSince time.Time `json:"updated_since"`
+	Limit        int       `json:"limit"`
+	Watchlist    bool      `json:"watchlist"`
+	Tags         []string  `json:"tags"`
+}
+
+type BulkAnalysisResult struct {
+	Results []ProductAnalysisResult `json:"results"`
+	Summary PortfolioSummary        `json:"summary"`
+}
+
+type ProductAnalysisResult struct {
+	ProductID     string           `json:"product_id"`
+	OwnSKU        string           `json:"own_sku,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Analysis      *ProductAnalysis `json:"analysis,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	Present       bool             `json:"present"`
+	Leading       bool             `json:"leading"`
+	RevenueAtRisk float64          `json:"revenue_at_risk"`
+}
+
+type PortfolioSummary struct {
+	MerchantID    string  `json:"merchant_id"`
+	Products      int     `json:"products"`
+	Analyzed      int     `json:"analyzed"`
+	Failed        int     `json:"failed"`
+	Present       int     `json:"present"`
+	Leading       int     `json:"leading"`
+	WithDumping   int     `json:"with_dumping"`
+	RevenueAtRisk float64 `json:"revenue_at_risk"`
+}
@@ -0,0 +1,16 @@
+package models
+
+type BuyBoxPrediction struct {
+	TrainingSnapshots int                `json:"training_snapshots"`
+	Factors           map[string]float64 `json:"factors"`
+	Sellers           []BuyBoxEstimate   `json:"sellers"`
+}
+
+type BuyBoxEstimate struct {
+	SellerID     string  `json:"seller_id"`
+	Name         string  `json:"name"`
+	Price        float64 `json:"price"`
+	Position     int     `json:"position"`
+	Probability  float64 `json:"probability"`
+	TopSlotPrice float64 `json:"top_slot_price"`
+}
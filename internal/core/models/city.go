@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// DefaultCityID - код Алматы в Kaspi, город записей без явно указанного города
+const DefaultCityID = "750000000"
+
+type CityComparison struct {
+	ProductID           string             `json:"product_id"`
+	Cities              []CityPriceSummary `json:"cities"`
+	CheapestCityID      string             `json:"cheapest_city_id"`
+	MostExpensiveCityID string             `json:"most_expensive_city_id"`
+	CitySpreadPercent   float64            `json:"city_spread_percent"`
+}
+
+type CityPriceSummary struct {
+	CityID       string    `json:"city_id"`
+	MinPrice     float64   `json:"min_price"`
+	MedianPrice  float64   `json:"median_price"`
+	AvgPrice     float64   `json:"avg_price"`
+	OptimalPrice float64   `json:"optimal_price"`
+	Sellers      int       `json:"sellers"`
+	Timestamp    time.Time `json:"timestamp"`
+}
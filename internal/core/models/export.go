@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// HistoryFilter - выборка истории цен для выгрузки. Пустые поля не ограничивают выборку.
+type HistoryFilter struct {
+	ProductIDs []string  `json:"product_ids"`
+	CityID     string    `json:"city_id"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+}
+
+// PurgeResult - число удаленных (при DryRun - подлежащих удалению) записей по таблицам
+type PurgeResult struct {
+	Before            time.Time `json:"before"`
+	DryRun            bool      `json:"dry_run"`
+	PriceHistory      int64     `json:"price_history"`
+	ProductInfo       int64     `json:"product_info"`
+	QuarantinedOffers int64     `json:"quarantined_offers"`
+	Jobs              int64     `json:"jobs"`
+	RateLimitBuckets  int64     `json:"rate_limit_buckets"`
+	RateLimitQuotas   int64     `json:"rate_limit_quotas"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// HealthStatus - состояние сервиса или компонента
+type HealthStatus string
+
+const (
+	HealthHealthy   HealthStatus = "healthy"
+	HealthDegraded  HealthStatus = "degraded"  // работает, но показатель вышел за порог
+	HealthUnhealthy HealthStatus = "unhealthy" // не работает
+)
+
+// ComponentHealth - результат проверки одного компонента. Отказ критичного компонента
+// делает весь сервис unhealthy, остальных - degraded.
+type ComponentHealth struct {
+	Name      string         `json:"name"`
+	Status    HealthStatus   `json:"status"`
+	Critical  bool           `json:"critical"`
+	LatencyMs float64        `json:"latency_ms"`
+	Message   string         `json:"message,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// HealthReport - ответ /health
+type HealthReport struct {
+	Status     HealthStatus      `json:"status"`
+	Components []ComponentHealth `json:"components"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
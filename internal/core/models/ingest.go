@@ -0,0 +1,36 @@
+package models
+
+type IngestSummary struct {
+	Lines     int            `json:"lines"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Results   []IngestResult `json:"results"`
+}
+
+type IngestResult struct {
+	Line         int     `json:"line"`
+	ProductID    string  `json:"product_id,omitempty"`
+	Status       string  `json:"status"`
+	Error        string  `json:"error,omitempty"`
+	Offers       int     `json:"offers"`
+	Quarantined  int     `json:"quarantined"`
+	MinPrice     float64 `json:"min_price,omitempty"`
+	OptimalPrice float64 `json:"optimal_price,omitempty"`
+}
+
+// ImportSummary - результат импорта таблицы: ошибки по строкам и результат загрузки по товарам
+type ImportSummary struct {
+	Rows      int              `json:"rows"`
+	Invalid   int              `json:"invalid"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Errors    []ImportRowError `json:"errors"`
+	Products  []IngestResult   `json:"products"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+type Job struct {
+	ID          int64            `json:"id"`
+	WorkspaceID string           `json:"-"`
+	ProductID   string           `json:"product_id"`
+	Status      string           `json:"status"`
+	Attempts    int              `json:"attempts"`
+	MaxAttempts int              `json:"max_attempts"`
+	Payload     json.RawMessage  `json:"-"`
+	Result      *ProductAnalysis `json:"result,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	RunAt       time.Time        `json:"run_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+}
+
+// QueueStats - состояние очереди загрузки для проверки здоровья
+type QueueStats struct {
+	Queued         int        // задачи в очереди, включая ожидающие повтора
+	Running        int        // задачи в обработке
+	OldestQueuedAt *time.Time // срок самой давней задачи, которую пора обработать
+}
@@ -3,9 +3,11 @@ package models
 import "time"
 
 type PriceHistory struct {
-	ID        int       `json:"id" db:"id"`
-	ProductID string    `json:"product_id" db:"product_id"`
-	SellerID  string    `json:"seller_id" db:"seller_id"`
-	Price     float64   `json:"price" db:"price"`
-	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+	ID          int       `json:"id" db:"id"`
+	WorkspaceID string    `json:"-" db:"workspace_id"`
+	ProductID   string    `json:"product_id" db:"product_id"`
+	CityID      string    `json:"city_id" db:"city_id"`
+	SellerID    string    `json:"seller_id" db:"seller_id"`
+	Price       float64   `json:"price" db:"price"`
+	Timestamp   time.Time `json:"timestamp" db:"timestamp"`
 }
@@ -4,6 +4,7 @@ import "time"
 
 type KaspiDataRequest struct {
 	ProductID string        `json:"product_id"`
+	CityID    string        `json:"city_id"`
 	Offers    ProductOffers `json:"offers"`
 }
 type ProductOffers struct {
@@ -32,7 +33,9 @@ type Offer struct {
 	DeliveryOptions         map[string]interface{} `json:"deliveryOptions"`
 }
 type ProductInfo struct {
-	ProductID string    `json:"product_id"`
-	Sellers   []Seller  `json:"sellers"`
-	Timestamp time.Time `json:"timestamp"`
+	WorkspaceID string    `json:"-"`
+	ProductID   string    `json:"product_id"`
+	CityID      string    `json:"city_id"`
+	Sellers     []Seller  `json:"sellers"`
+	Timestamp   time.Time `json:"timestamp"`
 }
@@ -1,12 +1,38 @@
 package models
 
 type ProductAnalysis struct {
-	ProductID      string   `json:"product_id"`
-	MinPrice       float64  `json:"min_price"`
-	AvgPrice       float64  `json:"avg_price"`
-	OptimalPrice   float64  `json:"optimal_price"`
-	DumpingSellers []Seller `json:"dumping_sellers"`
-	Sellers        []Seller `json:"sellers"`
-	TotalOffers    int      `json:"total_offers"`
-	AnalysisTime   string   `json:"analysis_time"`
+	ProductID          string             `json:"product_id"`
+	CityID             string             `json:"city_id"`
+	MinPrice           float64            `json:"min_price"`
+	MaxPrice           float64            `json:"max_price"`
+	AvgPrice           float64            `json:"avg_price"`
+	WeightedAvgPrice   float64            `json:"weighted_avg_price"`
+	MedianPrice        float64            `json:"median_price"`
+	P10Price           float64            `json:"p10_price"`
+	P25Price           float64            `json:"p25_price"`
+	P75Price           float64            `json:"p75_price"`
+	P90Price           float64            `json:"p90_price"`
+	StdDev             float64            `json:"std_dev"`
+	IQR                float64            `json:"iqr"`
+	PriceSpreadPercent float64            `json:"price_spread_percent"`
+	OptimalPrice       float64            `json:"optimal_price"`
+	DumpingSellers     []Seller           `json:"dumping_sellers"`
+	NoiseSellers       []Seller           `json:"noise_sellers"`
+	Sellers            []Seller           `json:"sellers"`
+	Competitors        []RankedSeller     `json:"competitors"`
+	Segments           []SegmentStats     `json:"segments"`
+	BuyBox             *BuyBoxPrediction  `json:"buy_box,omitempty"`
+	QuarantinedOffers  []QuarantinedOffer `json:"quarantined_offers,omitempty"`
+	TotalOffers        int                `json:"total_offers"`
+	AnalysisTime       string             `json:"analysis_time"`
+}
+
+type SegmentStats struct {
+	Segment      float64 `json:"segment"`
+	Count        int     `json:"count"`
+	MinPrice     float64 `json:"min_price"`
+	MaxPrice     float64 `json:"max_price"`
+	AvgPrice     float64 `json:"avg_price"`
+	MedianPrice  float64 `json:"median_price"`
+	DumpingCount int     `json:"dumping_count"`
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type QuarantinedOffer struct {
+	ID          int       `json:"id"`
+	WorkspaceID string    `json:"-"`
+	ProductID   string    `json:"product_id"`
+	CityID      string    `json:"city_id"`
+	MerchantID  string    `json:"merchant_id"`
+	Price       float64   `json:"price"`
+	Reasons     []string  `json:"reasons"`
+	Offer       *Offer    `json:"offer,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
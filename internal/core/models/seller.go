@@ -1,12 +1,23 @@
 package models
 
 type Seller struct {
-	ID        string  `json:"id"`
-	Name      string  `json:"name"`
-	Price     float64 `json:"price"`
-	Rating    float64 `json:"rating"`
-	Reviews   int     `json:"reviews"`
-	Purchases int     `json:"purchases"`
-	SKU       string  `json:"sku"`
-	Segment   float64 `json:"segment"`
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Price            float64 `json:"price"`
+	Rating           float64 `json:"rating"`
+	Reviews          int     `json:"reviews"`
+	Purchases        int     `json:"purchases"`
+	SKU              string  `json:"sku"`
+	Segment          float64 `json:"segment"`
+	Position         int     `json:"position"`
+	DeliveryType     string  `json:"delivery_type"`
+	DeliveryDuration string  `json:"delivery_duration"`
+	KaspiDelivery    bool    `json:"kaspi_delivery"`
+}
+
+type RankedSeller struct {
+	Seller
+	Rank           int     `json:"rank"`
+	Score          float64 `json:"score"`
+	BayesianRating float64 `json:"bayesian_rating"`
 }
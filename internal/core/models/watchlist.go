@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type WatchlistEntry struct {
+	WorkspaceID         string    `json:"-"`
+	ProductID           string    `json:"product_id"`
+	Tags                []string  `json:"tags"`
+	Cities              []string  `json:"cities"`
+	Priority            int       `json:"priority"`
+	PollIntervalSeconds int       `json:"poll_interval_seconds"`
+	OwnSKU              string    `json:"own_sku"`
+	Notes               string    `json:"notes"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DefaultWorkspaceID - рабочее пространство данных, сохраненных до появления рабочих пространств,
+// и запросов при выключенной проверке API ключей
+const DefaultWorkspaceID = "default"
+
+// Workspace - рабочее пространство команды: свои товары, история, список отслеживания и ключи
+type Workspace struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	OwnMerchantID string    `json:"own_merchant_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// UpdateWorkspaceRequest - изменяемые поля рабочего пространства
+type UpdateWorkspaceRequest struct {
+	Name          string `json:"name"`
+	OwnMerchantID string `json:"own_merchant_id"`
+}
@@ -3,13 +3,44 @@ package ports
 import (
 	"Mini-Quicko/internal/core/models"
 	"context"
+	"time"
 )
 
 type Repository interface {
-	SavePriceHistory(ctx context.Context, history *models.PriceHistory) error
-	GetPriceHistory(ctx context.Context, productID string, limit int) ([]models.PriceHistory, error)
-	GetProductInfo(ctx context.Context, productID string) (*models.ProductInfo, error)
-	SaveProductInfo(ctx context.Context, productInfo *models.ProductInfo) error
+	GetPriceHistory(ctx context.Context, workspaceID, productID, cityID string, limit int) ([]models.PriceHistory, error)
+	GetProductInfo(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductInfo, error)
+	SaveSnapshot(ctx context.Context, productInfo *models.ProductInfo) error
+	ListProductIDs(ctx context.Context, workspaceID, cityID string, filter models.ProductFilter) ([]string, error)
+	GetProductSnapshots(ctx context.Context, workspaceID, productID, cityID string, from, to time.Time) ([]models.ProductInfo, error)
+	ListProductCities(ctx context.Context, workspaceID, productID string) ([]string, error)
+	LastIngestionTime(ctx context.Context) (*time.Time, error)
+	SaveQuarantinedOffers(ctx context.Context, offers []models.QuarantinedOffer) error
+	GetQuarantinedOffers(ctx context.Context, workspaceID, productID string, limit int) ([]models.QuarantinedOffer, error)
+	CreateJob(ctx context.Context, job *models.Job) error
+	ClaimJob(ctx context.Context, lease time.Duration) (*models.Job, error)
+	UpdateJob(ctx context.Context, job *models.Job) error
+	ExtendJobLease(ctx context.Context, job *models.Job, until time.Time) error
+	GetJob(ctx context.Context, workspaceID string, id int64) (*models.Job, error)
+	QueueStats(ctx context.Context, now time.Time) (*models.QueueStats, error)
+	SaveWatchlistEntry(ctx context.Context, entry *models.WatchlistEntry) error
+	GetWatchlist(ctx context.Context, workspaceID string, tags []string) ([]models.WatchlistEntry, error)
+	GetWatchlistEntry(ctx context.Context, workspaceID, productID string) (*models.WatchlistEntry, error)
+	DeleteWatchlistEntry(ctx context.Context, workspaceID, productID string) (bool, error)
+	ExportPriceHistory(ctx context.Context, workspaceID string, filter models.HistoryFilter, fn func(models.PriceHistory) error) error
+	Purge(ctx context.Context, before time.Time, dryRun bool) (*models.PurgeResult, error)
+	CreateWorkspace(ctx context.Context, workspace *models.Workspace) (bool, error)
+	GetWorkspace(ctx context.Context, id string) (*models.Workspace, error)
+	ListWorkspaces(ctx context.Context) ([]models.Workspace, error)
+	UpdateWorkspace(ctx context.Context, workspace *models.Workspace) (bool, error)
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, workspaceID string) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, workspaceID string, id int64, at time.Time) (bool, error)
+	TouchAPIKey(ctx context.Context, id int64, at time.Time) error
+	TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error)
+	IncrementQuota(ctx context.Context, key string, day time.Time, limit int) (int, bool, error)
+	Migrate(ctx context.Context) (int, error)
+	PendingMigrations(ctx context.Context) ([]int, error)
 	HealthCheck(ctx context.Context) error
 	Close() error
 }
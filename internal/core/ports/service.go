@@ -3,12 +3,35 @@ package ports
 import (
 	"Mini-Quicko/internal/core/models"
 	"context"
+	"time"
 )
 
+// Service - бизнес-логика. Все данные принадлежат рабочему пространству workspaceID,
+// которое обработчики берут из API ключа, а подкоманды CLI - из флага -workspace.
 type Service interface {
-	AnalyzeProduct(ctx context.Context, productID string) (*models.ProductAnalysis, error)
-	GetPriceHistory(ctx context.Context, productID string) ([]models.PriceHistory, error)
-	GetProductInfo(ctx context.Context, productID string) (*models.ProductInfo, error)
-	SaveKaspiData(ctx context.Context, request *models.KaspiDataRequest) (*models.ProductAnalysis, error)
+	AnalyzeProduct(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductAnalysis, error)
+	AnalyzeProducts(ctx context.Context, workspaceID string, request *models.BulkAnalysisRequest) (*models.BulkAnalysisResult, error)
+	GetPriceHistory(ctx context.Context, workspaceID, productID, cityID string) ([]models.PriceHistory, error)
+	GetProductInfo(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductInfo, error)
+	CompareCities(ctx context.Context, workspaceID, productID string) (*models.CityComparison, error)
+	SaveKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (*models.ProductAnalysis, error)
+	Backtest(ctx context.Context, workspaceID string, request *models.BacktestRequest) (*models.BacktestReport, error)
+	GetQuarantinedOffers(ctx context.Context, workspaceID, productID string) ([]models.QuarantinedOffer, error)
+	EnqueueKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (*models.Job, error)
+	GetJob(ctx context.Context, workspaceID string, id int64) (*models.Job, error)
+	SaveWatchlistEntry(ctx context.Context, workspaceID string, entry *models.WatchlistEntry) error
+	GetWatchlist(ctx context.Context, workspaceID string, tags []string) ([]models.WatchlistEntry, error)
+	GetWatchlistEntry(ctx context.Context, workspaceID, productID string) (*models.WatchlistEntry, error)
+	DeleteWatchlistEntry(ctx context.Context, workspaceID, productID string) error
+	ExportPriceHistory(ctx context.Context, workspaceID string, filter models.HistoryFilter, fn func(models.PriceHistory) error) error
+	Purge(ctx context.Context, before time.Time, dryRun bool) (*models.PurgeResult, error)
+	CreateWorkspace(ctx context.Context, workspace *models.Workspace) error
+	GetWorkspace(ctx context.Context, id string) (*models.Workspace, error)
+	ListWorkspaces(ctx context.Context) ([]models.Workspace, error)
+	UpdateWorkspace(ctx context.Context, workspace *models.Workspace) error
+	CreateAPIKey(ctx context.Context, workspaceID string, request *models.CreateAPIKeyRequest) (*models.NewAPIKey, error)
+	ListAPIKeys(ctx context.Context, workspaceID string) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, workspaceID string, id int64) error
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
 	HealthCheck(ctx context.Context) error
 }
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// csvWriter пишет CSV так, чтобы файл открывался в Excel без импорта:
+// UTF-8 с BOM, для русского языка - разделитель ";" и десятичная запятая.
+type csvWriter struct {
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	lang    Lang
+	columns []Column
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, lang Lang, columns []Column) (*csvWriter, error) {
+	buf := bufio.NewWriter(w)
+	if _, err := buf.WriteString("\ufeff"); err != nil {
+		return nil, err
+	}
+
+	writer := &csvWriter{
+		buf:     buf,
+		csv:     csv.NewWriter(buf),
+		lang:    lang,
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+	if lang == LangRU {
+		writer.csv.Comma = ';'
+	}
+
+	for i, column := range columns {
+		writer.record[i] = column.Title(lang)
+	}
+	if err := writer.csv.Write(writer.record); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *csvWriter) WriteRow(values ...interface{}) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("row has %d values, expected %d", len(values), len(w.columns))
+	}
+	for i, value := range values {
+		w.record[i] = w.format(w.columns[i].Kind, value)
+	}
+	return w.csv.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+func (w *csvWriter) format(kind Kind, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	switch kind {
+	case Tenge:
+		// Цены на Kaspi в целых тенге, тиыны показываем только если они есть
+		if v, ok := asFloat(value); ok {
+			if v == math.Trunc(v) {
+				return strconv.FormatFloat(v, 'f', 0, 64)
+			}
+			return w.decimal(v, 2)
+		}
+	case Number, Percent:
+		if v, ok := asFloat(value); ok {
+			return w.decimal(v, 2)
+		}
+	case Integer:
+		if v, ok := asFloat(value); ok {
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+	case Bool:
+		if v, ok := value.(bool); ok {
+			return boolText(v, w.lang)
+		}
+	case Time:
+		if t, ok := asTime(value); ok {
+			return t.Format(timeLayout)
+		}
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+func (w *csvWriter) decimal(value float64, precision int) string {
+	text := strconv.FormatFloat(value, 'f', precision, 64)
+	if w.lang == LangRU {
+		return strings.Replace(text, ".", ",", 1)
+	}
+	return text
+}
@@ -0,0 +1,145 @@
+// Package export пишет табличные выгрузки (CSV, XLSX) для работы с данными в таблицах.
+// Строки пишутся по мере поступления, без буферизации всей таблицы в памяти.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat разбирает значение параметра format
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(value)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("unsupported format %q: expected csv or xlsx", value)
+}
+
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Lang - язык заголовков и форматирования чисел
+type Lang string
+
+const (
+	LangRU Lang = "ru"
+	LangEN Lang = "en"
+)
+
+// ParseLang выбирает язык по параметру lang или заголовку Accept-Language, по умолчанию - русский
+func ParseLang(value string) Lang {
+	for _, part := range strings.Split(value, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch {
+		case strings.HasPrefix(tag, "ru"), strings.HasPrefix(tag, "kk"):
+			return LangRU
+		case strings.HasPrefix(tag, "en"):
+			return LangEN
+		}
+	}
+	return LangRU
+}
+
+// Kind определяет форматирование значения колонки
+type Kind int
+
+const (
+	Text    Kind = iota
+	Tenge        // денежная сумма в тенге
+	Number       // дробное число
+	Integer      // целое число
+	Percent      // значение в процентах (12.5 = 12.5%)
+	Bool
+	Time
+)
+
+type Column struct {
+	Key  string
+	Kind Kind
+}
+
+// Title возвращает заголовок колонки на языке lang
+func (c Column) Title(lang Lang) string {
+	titles, ok := columnTitles[c.Key]
+	if !ok {
+		return c.Key
+	}
+	if lang == LangEN {
+		return titles[1]
+	}
+	return titles[0]
+}
+
+// Writer пишет строки таблицы; значения передаются в порядке колонок
+type Writer interface {
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+// NewWriter создает writer нужного формата и сразу пишет строку заголовков
+func NewWriter(w io.Writer, format Format, lang Lang, sheet string, columns []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, lang, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, lang, sheet, columns)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// timeLayout - формат даты и времени в CSV
+const timeLayout = "2006-01-02 15:04:05"
+
+func asFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func asTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case *time.Time:
+		if v != nil && !v.IsZero() {
+			return *v, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func boolText(value bool, lang Lang) string {
+	switch {
+	case lang == LangEN && value:
+		return "yes"
+	case lang == LangEN:
+		return "no"
+	case value:
+		return "да"
+	}
+	return "нет"
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"strings"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// columnTitles - заголовки колонок: русский, английский
+var columnTitles = map[string][2]string{
+	"product_id":         {"Товар", "Product ID"},
+	"city_id":            {"Город", "City ID"},
+	"seller_id":          {"ID продавца", "Seller ID"},
+	"seller_name":        {"Продавец", "Seller"},
+	"price":              {"Цена", "Price"},
+	"timestamp":          {"Время", "Timestamp"},
+	"rank":               {"Место по репутации", "Reputation rank"},
+	"position":           {"Позиция в выдаче", "Listing position"},
+	"premium_percent":    {"Разница с мин. ценой", "Premium over min"},
+	"rating":             {"Рейтинг", "Rating"},
+	"reviews":            {"Отзывы", "Reviews"},
+	"purchases":          {"Покупки", "Purchases"},
+	"segment":            {"Сегмент", "Segment"},
+	"score":              {"Оценка репутации", "Reputation score"},
+	"bayesian_rating":    {"Байесовский рейтинг", "Bayesian rating"},
+	"first_probability":  {"Вероятность первого места", "First place probability"},
+	"top_slot_price":     {"Цена для первого места", "Top slot price"},
+	"dumping":            {"Демпинг", "Dumping"},
+	"noise":              {"Шум", "Noise"},
+	"own_sku":            {"Наш SKU", "Own SKU"},
+	"tags":               {"Теги", "Tags"},
+	"sellers":            {"Продавцов", "Sellers"},
+	"min_price":          {"Мин. цена", "Min price"},
+	"median_price":       {"Медиана", "Median price"},
+	"avg_price":          {"Средняя цена", "Average price"},
+	"max_price":          {"Макс. цена", "Max price"},
+	"optimal_price":      {"Оптимальная цена", "Optimal price"},
+	"dumping_sellers":    {"Демпингующих", "Dumping sellers"},
+	"present":            {"Мы в выдаче", "Present"},
+	"leading":            {"Мы лидер", "Leading"},
+	"revenue_at_risk":    {"Выручка под риском", "Revenue at risk"},
+	"error":              {"Ошибка", "Error"},
+	"price_spread":       {"Разброс цен", "Price spread"},
+	"quarantined_offers": {"В карантине", "Quarantined"},
+}
+
+var HistoryColumns = []Column{
+	{"product_id", Text},
+	{"city_id", Text},
+	{"seller_id", Text},
+	{"price", Tenge},
+	{"timestamp", Time},
+}
+
+func HistoryRow(history models.PriceHistory) []interface{} {
+	return []interface{}{history.ProductID, history.CityID, history.SellerID, history.Price, history.Timestamp}
+}
+
+// AnalysisColumns - строка на каждого продавца из анализа товара
+var AnalysisColumns = []Column{
+	{"product_id", Text},
+	{"city_id", Text},
+	{"rank", Integer},
+	{"position", Integer},
+	{"seller_id", Text},
+	{"seller_name", Text},
+	{"price", Tenge},
+	{"premium_percent", Percent},
+	{"rating", Number},
+	{"reviews", Integer},
+	{"purchases", Integer},
+	{"segment", Number},
+	{"score", Number},
+	{"bayesian_rating", Number},
+	{"first_probability", Percent},
+	{"top_slot_price", Tenge},
+	{"dumping", Bool},
+	{"noise", Bool},
+	{"optimal_price", Tenge},
+}
+
+// AnalysisRows раскладывает анализ товара на строки по продавцам в порядке ранга репутации
+func AnalysisRows(analysis *models.ProductAnalysis) [][]interface{} {
+	dumping := sellerSet(analysis.DumpingSellers)
+	noise := sellerSet(analysis.NoiseSellers)
+	estimates := make(map[string]models.BuyBoxEstimate)
+	if analysis.BuyBox != nil {
+		for _, estimate := range analysis.BuyBox.Sellers {
+			estimates[estimate.SellerID] = estimate
+		}
+	}
+
+	rows := make([][]interface{}, 0, len(analysis.Competitors))
+	for _, seller := range analysis.Competitors {
+		var premium, probability, topSlotPrice interface{}
+		if analysis.MinPrice > 0 {
+			premium = (seller.Price - analysis.MinPrice) / analysis.MinPrice * 100
+		}
+		if estimate, ok := estimates[seller.ID]; ok {
+			probability = estimate.Probability * 100
+			if estimate.TopSlotPrice > 0 {
+				topSlotPrice = estimate.TopSlotPrice
+			}
+		}
+
+		rows = append(rows, []interface{}{
+			analysis.ProductID, analysis.CityID, seller.Rank, seller.Position, seller.ID, seller.Name, seller.Price, premium,
+			seller.Rating, seller.Reviews, seller.Purchases, seller.Segment, seller.Score, seller.BayesianRating,
+			probability, topSlotPrice, dumping[seller.ID], noise[seller.ID], analysis.OptimalPrice,
+		})
+	}
+	return rows
+}
+
+// BulkColumns - строка на каждый товар пакетного анализа
+var BulkColumns = []Column{
+	{"product_id", Text},
+	{"city_id", Text},
+	{"own_sku", Text},
+	{"tags", Text},
+	{"sellers", Integer},
+	{"min_price", Tenge},
+	{"median_price", Tenge},
+	{"avg_price", Tenge},
+	{"max_price", Tenge},
+	{"price_spread", Percent},
+	{"optimal_price", Tenge},
+	{"dumping_sellers", Integer},
+	{"present", Bool},
+	{"leading", Bool},
+	{"revenue_at_risk", Tenge},
+	{"error", Text},
+}
+
+func BulkRow(result models.ProductAnalysisResult) []interface{} {
+	row := []interface{}{result.ProductID, nil, result.OwnSKU, strings.Join(result.Tags, ", ")}
+	if analysis := result.Analysis; analysis != nil {
+		row[1] = analysis.CityID
+		row = append(row, len(analysis.Sellers), analysis.MinPrice, analysis.MedianPrice, analysis.AvgPrice, analysis.MaxPrice,
+			analysis.PriceSpreadPercent, analysis.OptimalPrice, len(analysis.DumpingSellers))
+	} else {
+		row = append(row, nil, nil, nil, nil, nil, nil, nil, nil)
+	}
+	return append(row, result.Present, result.Leading, result.RevenueAtRisk, result.Error)
+}
+
+func sellerSet(sellers []models.Seller) map[string]bool {
+	set := make(map[string]bool, len(sellers))
+	for _, seller := range sellers {
+		set[seller.ID] = true
+	}
+	return set
+}
@@ -0,0 +1,235 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxWriter пишет минимальную книгу Office Open XML с одним листом.
+// Строки листа пишутся потоково в zip, строки текста хранятся inline (без sharedStrings),
+// числа - настоящими числовыми ячейками с форматом, чтобы по ним работали формулы и сортировка.
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	lang    Lang
+	columns []Column
+	row     int
+}
+
+// Индексы стилей из xlsxStyles (cellXfs)
+const (
+	styleHeader  = 1
+	styleTenge   = 2
+	styleNumber  = 3
+	styleInteger = 4
+	stylePercent = 5
+	styleTime    = 6
+)
+
+var numberStyles = map[Kind]int{Tenge: styleTenge, Number: styleNumber, Integer: styleInteger, Percent: stylePercent}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+// Формат тенге: разделитель разрядов и знак ₸, десятичные знаки и разделители Excel берет из локали пользователя
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="2"><numFmt numFmtId="164" formatCode="#,##0 &quot;₸&quot;"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="7">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="10" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>`
+
+const xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>
+<sheetData>`
+
+const xlsxSheetFooter = `</sheetData></worksheet>`
+
+func newXLSXWriter(w io.Writer, lang Lang, sheet string, columns []Column) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escapeXML(sheetName(sheet)))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// Лист пишется последним, чтобы строки можно было дописывать до Close
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &xlsxWriter{
+		zip:     zw,
+		sheet:   bufio.NewWriter(f),
+		lang:    lang,
+		columns: columns,
+	}
+	writer.sheet.WriteString(xlsxSheetHeader)
+
+	titles := make([]interface{}, len(columns))
+	for i, column := range columns {
+		titles[i] = column.Title(lang)
+	}
+	if err := writer.writeRow(titles, true); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *xlsxWriter) WriteRow(values ...interface{}) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("row has %d values, expected %d", len(values), len(w.columns))
+	}
+	return w.writeRow(values, false)
+}
+
+func (w *xlsxWriter) writeRow(values []interface{}, header bool) error {
+	w.row++
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.row)
+	for i, value := range values {
+		if header {
+			w.inlineString(value.(string), styleHeader)
+			continue
+		}
+		w.cell(w.columns[i].Kind, value)
+	}
+	_, err := w.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (w *xlsxWriter) cell(kind Kind, value interface{}) {
+	if value == nil {
+		w.sheet.WriteString(`<c/>`)
+		return
+	}
+
+	switch kind {
+	case Tenge, Number, Integer, Percent:
+		if v, ok := asFloat(value); ok {
+			if kind == Percent {
+				v /= 100
+			}
+			fmt.Fprintf(w.sheet, `<c s="%d"><v>%s</v></c>`, numberStyles[kind], strconv.FormatFloat(v, 'f', -1, 64))
+			return
+		}
+	case Bool:
+		if v, ok := value.(bool); ok {
+			w.inlineString(boolText(v, w.lang), 0)
+			return
+		}
+	case Time:
+		if t, ok := asTime(value); ok {
+			fmt.Fprintf(w.sheet, `<c s="%d"><v>%s</v></c>`, styleTime, strconv.FormatFloat(excelSerial(t), 'f', -1, 64))
+		} else {
+			w.sheet.WriteString(`<c/>`)
+		}
+		return
+	}
+
+	w.inlineString(fmt.Sprint(value), 0)
+}
+
+func (w *xlsxWriter) inlineString(text string, style int) {
+	if style != 0 {
+		fmt.Fprintf(w.sheet, `<c s="%d" t="inlineStr"><is><t xml:space="preserve">`, style)
+	} else {
+		w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	}
+	w.sheet.WriteString(escapeXML(text))
+	w.sheet.WriteString(`</t></is></c>`)
+}
+
+func (w *xlsxWriter) Close() error {
+	if _, err := w.sheet.WriteString(xlsxSheetFooter); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+// excelEpoch - начало отсчета дат Excel (с учетом ошибки 1900 года)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// excelSerial переводит время в число дней Excel; время показывается как есть, без перевода в UTC
+func excelSerial(t time.Time) float64 {
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return local.Sub(excelEpoch).Hours() / 24
+}
+
+func escapeXML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// sheetName приводит имя листа к ограничениям Excel: до 31 символа, без []:*?/\
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Sheet1"
+	}
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	return name
+}
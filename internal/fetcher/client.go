@@ -0,0 +1,138 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// ClientConfig - параметры запросов к эндпоинту офферов Kaspi
+type ClientConfig struct {
+	URL       string // шаблон адреса, {productId} заменяется на ID товара
+	CityID    string // город по умолчанию
+	PageSize  int
+	MaxPages  int
+	UserAgent string
+	Timeout   time.Duration
+}
+
+// Client получает офферы товара постранично и собирает их в KaspiDataRequest
+type Client struct {
+	http *http.Client
+	cfg  ClientConfig
+}
+
+// StatusError - ответ эндпоинта с неуспешным HTTP статусом
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("offers endpoint returned status %d", e.StatusCode)
+}
+
+type offersPageRequest struct {
+	CityID string `json:"cityId"`
+	ID     string `json:"id"`
+	Limit  int    `json:"limit"`
+	Page   int    `json:"page"`
+	Sort   bool   `json:"sort"`
+}
+
+func NewClient(cfg ClientConfig) *Client {
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = 64
+	}
+	if cfg.MaxPages <= 0 {
+		cfg.MaxPages = 10
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+
+	return &Client{
+		http: &http.Client{Timeout: cfg.Timeout},
+		cfg:  cfg,
+	}
+}
+
+// FetchOffers загружает страницы офферов в городе cityID (пустой - город по умолчанию),
+// пока не соберет offersCount или не кончатся страницы
+func (c *Client) FetchOffers(ctx context.Context, productID, cityID string) (*models.KaspiDataRequest, error) {
+	if cityID == "" {
+		cityID = c.cfg.CityID
+	}
+	request := &models.KaspiDataRequest{ProductID: productID, CityID: cityID}
+
+	for page := 0; page < c.cfg.MaxPages; page++ {
+		offers, err := c.fetchPage(ctx, productID, cityID, page)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Offers.Offers = append(request.Offers.Offers, offers.Offers...)
+		request.Offers.Total = offers.Total
+		request.Offers.OffersCount = offers.OffersCount
+
+		if len(offers.Offers) < c.cfg.PageSize || len(request.Offers.Offers) >= offers.OffersCount {
+			break
+		}
+	}
+
+	return request, nil
+}
+
+func (c *Client) fetchPage(ctx context.Context, productID, cityID string, page int) (*models.ProductOffers, error) {
+	body, err := json.Marshal(offersPageRequest{
+		CityID: cityID,
+		ID:     productID,
+		Limit:  c.cfg.PageSize,
+		Page:   page,
+		Sort:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.ReplaceAll(c.cfg.URL, "{productId}", productID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", c.cfg.UserAgent)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			statusErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, statusErr
+	}
+
+	var offers models.ProductOffers
+	if err := json.NewDecoder(resp.Body).Decode(&offers); err != nil {
+		return nil, fmt.Errorf("failed to decode offers page %d: %w", page, err)
+	}
+
+	return &offers, nil
+}
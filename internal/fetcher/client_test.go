@@ -0,0 +1,250 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// separatorLine - строка из дефисов между документами json.txt
+var separatorLine = regexp.MustCompile(`(?m)^-{3,}\s*$`)
+
+// loadRecorded читает записанные ответы Kaspi из json.txt: документы разделены строками из дефисов
+func loadRecorded(t *testing.T) []models.KaspiDataRequest {
+	t.Helper()
+
+	data, err := os.ReadFile("../../json.txt")
+	if err != nil {
+		t.Fatalf("read json.txt: %v", err)
+	}
+
+	var requests []models.KaspiDataRequest
+	for i, document := range separatorLine.Split(string(data), -1) {
+		if strings.TrimSpace(document) == "" {
+			continue
+		}
+		var request models.KaspiDataRequest
+		if err := json.Unmarshal([]byte(document), &request); err != nil {
+			t.Fatalf("json.txt document %d: %v", i+1, err)
+		}
+		requests = append(requests, request)
+	}
+	if len(requests) == 0 {
+		t.Fatal("json.txt has no documents")
+	}
+	return requests
+}
+
+// fakeResponse - ответ, который fakeKaspi вернет вместо страницы офферов
+type fakeResponse struct {
+	status     int
+	retryAfter string
+}
+
+// fakeKaspi - локальный эндпоинт офферов, отдающий записанные офферы постранично, как Kaspi
+type fakeKaspi struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	products  map[string]models.ProductOffers
+	failures  []fakeResponse // ответы с ошибкой, отдаются по одному перед страницами
+	requests  []offersPageRequest
+	paths     []string
+	userAgent string
+}
+
+func newFakeKaspi(t *testing.T, recorded ...models.KaspiDataRequest) *fakeKaspi {
+	t.Helper()
+
+	f := &fakeKaspi{products: make(map[string]models.ProductOffers)}
+	for _, request := range recorded {
+		f.products[request.ProductID] = request.Offers
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeKaspi) serve(w http.ResponseWriter, r *http.Request) {
+	var page offersPageRequest
+	if err := json.NewDecoder(r.Body).Decode(&page); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, page)
+	f.paths = append(f.paths, r.URL.Path)
+	f.userAgent = r.UserAgent()
+	if len(f.failures) > 0 {
+		failure := f.failures[0]
+		f.failures = f.failures[1:]
+		f.mu.Unlock()
+		if failure.retryAfter != "" {
+			w.Header().Set("Retry-After", failure.retryAfter)
+		}
+		w.WriteHeader(failure.status)
+		return
+	}
+	offers, ok := f.products[page.ID]
+	f.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	start := min(page.Page*page.Limit, len(offers.Offers))
+	end := min(start+page.Limit, len(offers.Offers))
+	offers.Offers = offers.Offers[start:end]
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(offers)
+}
+
+func (f *fakeKaspi) fail(responses ...fakeResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, responses...)
+}
+
+func (f *fakeKaspi) pageRequests() []offersPageRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]offersPageRequest(nil), f.requests...)
+}
+
+func (f *fakeKaspi) url() string {
+	return f.URL + "/yml/offer-view/offers/{productId}"
+}
+
+func TestFetchOffersPagesThroughRecordedOffers(t *testing.T) {
+	for i, recorded := range loadRecorded(t) {
+		server := newFakeKaspi(t, recorded)
+		client := NewClient(ClientConfig{URL: server.url(), CityID: "750000000", PageSize: 2, UserAgent: "test-agent"})
+
+		request, err := client.FetchOffers(context.Background(), recorded.ProductID, "710000000")
+		if err != nil {
+			t.Fatalf("document %d: FetchOffers: %v", i+1, err)
+		}
+
+		if request.ProductID != recorded.ProductID || request.CityID != "710000000" {
+			t.Errorf("document %d: got product %q city %q", i+1, request.ProductID, request.CityID)
+		}
+		if request.Offers.Total != recorded.Offers.Total || request.Offers.OffersCount != recorded.Offers.OffersCount {
+			t.Errorf("document %d: got total %d offersCount %d, want %d and %d", i+1,
+				request.Offers.Total, request.Offers.OffersCount, recorded.Offers.Total, recorded.Offers.OffersCount)
+		}
+		if !reflect.DeepEqual(request.Offers.Offers, recorded.Offers.Offers) {
+			t.Errorf("document %d: offers differ from json.txt:\ngot  %+v\nwant %+v", i+1, request.Offers.Offers, recorded.Offers.Offers)
+		}
+
+		// Страницы по 2 оффера, последняя неполная страница завершает загрузку
+		pages := server.pageRequests()
+		if want := len(recorded.Offers.Offers)/2 + 1; len(pages) != want {
+			t.Errorf("document %d: %d page requests, want %d", i+1, len(pages), want)
+		}
+		for page, body := range pages {
+			want := offersPageRequest{CityID: "710000000", ID: recorded.ProductID, Limit: 2, Page: page, Sort: true}
+			if body != want {
+				t.Errorf("document %d: page request %d = %+v, want %+v", i+1, page, body, want)
+			}
+		}
+		if path := "/yml/offer-view/offers/" + recorded.ProductID; server.paths[0] != path {
+			t.Errorf("document %d: requested %q, want %q", i+1, server.paths[0], path)
+		}
+		if server.userAgent != "test-agent" {
+			t.Errorf("document %d: User-Agent %q", i+1, server.userAgent)
+		}
+	}
+}
+
+func TestFetchOffersStopsPaging(t *testing.T) {
+	recorded := loadRecorded(t)[0]
+
+	tests := []struct {
+		name        string
+		offersCount int
+		pageSize    int
+		maxPages    int
+		wantPages   int
+		wantOffers  int
+	}{
+		{name: "max pages", pageSize: 1, maxPages: 2, wantPages: 2, wantOffers: 2},
+		{name: "offers count reached", offersCount: 2, pageSize: 2, maxPages: 10, wantPages: 1, wantOffers: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := recorded
+			if tt.offersCount > 0 {
+				product.Offers.OffersCount = tt.offersCount
+			}
+			server := newFakeKaspi(t, product)
+			client := NewClient(ClientConfig{URL: server.url(), PageSize: tt.pageSize, MaxPages: tt.maxPages})
+
+			request, err := client.FetchOffers(context.Background(), product.ProductID, "")
+			if err != nil {
+				t.Fatalf("FetchOffers: %v", err)
+			}
+			if got := len(server.pageRequests()); got != tt.wantPages {
+				t.Errorf("%d page requests, want %d", got, tt.wantPages)
+			}
+			if got := len(request.Offers.Offers); got != tt.wantOffers {
+				t.Errorf("%d offers, want %d", got, tt.wantOffers)
+			}
+		})
+	}
+}
+
+func TestFetchOffersUsesDefaultCity(t *testing.T) {
+	recorded := loadRecorded(t)[0]
+	server := newFakeKaspi(t, recorded)
+	client := NewClient(ClientConfig{URL: server.url(), CityID: "750000000"})
+
+	request, err := client.FetchOffers(context.Background(), recorded.ProductID, "")
+	if err != nil {
+		t.Fatalf("FetchOffers: %v", err)
+	}
+	if request.CityID != "750000000" || server.pageRequests()[0].CityID != "750000000" {
+		t.Errorf("city %q, requested %q, want the default city", request.CityID, server.pageRequests()[0].CityID)
+	}
+}
+
+func TestFetchOffersStatusError(t *testing.T) {
+	tests := []struct {
+		name           string
+		response       fakeResponse
+		wantRetryAfter time.Duration
+	}{
+		{name: "rate limited", response: fakeResponse{status: http.StatusTooManyRequests, retryAfter: "7"}, wantRetryAfter: 7 * time.Second},
+		{name: "unavailable", response: fakeResponse{status: http.StatusServiceUnavailable}},
+		{name: "server error with invalid Retry-After", response: fakeResponse{status: http.StatusInternalServerError, retryAfter: "soon"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorded := loadRecorded(t)[0]
+			server := newFakeKaspi(t, recorded)
+			server.fail(tt.response)
+			client := NewClient(ClientConfig{URL: server.url()})
+
+			_, err := client.FetchOffers(context.Background(), recorded.ProductID, "")
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("error %v, want *StatusError", err)
+			}
+			if statusErr.StatusCode != tt.response.status || statusErr.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("got status %d retry after %s, want %d and %s",
+					statusErr.StatusCode, statusErr.RetryAfter, tt.response.status, tt.wantRetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/logging"
+)
+
+// Target - отслеживаемый товар рабочего пространства в городе и интервал его опроса (0 - интервал по умолчанию).
+// Из нескольких товаров, которым пора обновиться, первым опрашивается товар с большим приоритетом.
+type Target struct {
+	WorkspaceID string
+	ProductID   string
+	CityID      string
+	Interval    time.Duration
+	Priority    int
+}
+
+func (t Target) key() string {
+	return t.WorkspaceID + "/" + t.ProductID + "@" + t.CityID
+}
+
+// Source возвращает актуальный список отслеживаемых товаров
+type Source interface {
+	Targets(ctx context.Context) ([]Target, error)
+}
+
+// StaticSource - фиксированный список товаров из конфигурации, опрашиваемых в каждом из городов.
+// Данные сохраняются в WorkspaceID, а если он не задан - в рабочее пространство по умолчанию.
+type StaticSource struct {
+	WorkspaceID string
+	ProductIDs  []string
+	Cities      []string
+}
+
+func (s StaticSource) Targets(ctx context.Context) ([]Target, error) {
+	workspaceID := s.WorkspaceID
+	if workspaceID == "" {
+		workspaceID = models.DefaultWorkspaceID
+	}
+
+	targets := make([]Target, 0, len(s.ProductIDs))
+	for _, productID := range s.ProductIDs {
+		for _, cityID := range citiesOrDefault(s.Cities) {
+			targets = append(targets, Target{WorkspaceID: workspaceID, ProductID: productID, CityID: cityID})
+		}
+	}
+	return targets, nil
+}
+
+// WatchlistSource - товары из списков отслеживания всех рабочих пространств, при заданных тегах -
+// только с этими тегами. Товар опрашивается в городах из записи, а если они не заданы - в DefaultCities.
+type WatchlistSource struct {
+	Service       ports.Service
+	Tags          []string
+	DefaultCities []string
+}
+
+func (s WatchlistSource) Targets(ctx context.Context) ([]Target, error) {
+	workspaces, err := s.Service.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	for _, workspace := range workspaces {
+		entries, err := s.Service.GetWatchlist(ctx, workspace.ID, s.Tags)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			cities := entry.Cities
+			if len(cities) == 0 {
+				cities = s.DefaultCities
+			}
+			for _, cityID := range citiesOrDefault(cities) {
+				targets = append(targets, Target{
+					WorkspaceID: workspace.ID,
+					ProductID:   entry.ProductID,
+					CityID:      cityID,
+					Interval:    time.Duration(entry.PollIntervalSeconds) * time.Second,
+					Priority:    entry.Priority,
+				})
+			}
+		}
+	}
+	return targets, nil
+}
+
+// citiesOrDefault подставляет город клиента по умолчанию (пустой CityID), если города не заданы
+func citiesOrDefault(cities []string) []string {
+	if len(cities) == 0 {
+		return []string{""}
+	}
+	return cities
+}
+
+// MultiSource объединяет несколько источников, первый источник имеет приоритет при повторах
+type MultiSource []Source
+
+func (m MultiSource) Targets(ctx context.Context) ([]Target, error) {
+	seen := make(map[string]bool)
+	var targets []Target
+	for _, source := range m {
+		sourceTargets, err := source.Targets(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range sourceTargets {
+			if seen[target.key()] {
+				continue
+			}
+			seen[target.key()] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// SchedulerConfig - параметры расписания опроса
+type SchedulerConfig struct {
+	Interval        time.Duration // интервал опроса товара по умолчанию
+	Jitter          float64       // случайное отклонение интервала, доля от 0 до 1
+	RatePerSecond   float64       // ограничение запросов к Kaspi
+	MaxBackoff      time.Duration
+	RefreshInterval time.Duration // как часто перечитывать список товаров
+}
+
+// clock - источник времени планировщика, в тестах подменяется
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// rateLimiter пропускает запросы не чаще одного за interval
+type rateLimiter struct {
+	clock    clock
+	interval time.Duration
+	next     time.Time
+}
+
+// wait ждет, пока можно отправить следующий запрос
+func (l *rateLimiter) wait(ctx context.Context) error {
+	now := l.clock.Now()
+	if delay := l.next.Sub(now); delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.clock.After(delay):
+		}
+		now = l.next
+	}
+	l.next = now.Add(l.interval)
+	return nil
+}
+
+type schedule struct {
+	target   Target
+	nextRun  time.Time
+	failures int
+}
+
+// Scheduler опрашивает товары по расписанию и передает данные в SaveKaspiData
+type Scheduler struct {
+	client  *Client
+	service ports.Service
+	source  Source
+	cfg     SchedulerConfig
+	clock   clock
+	random  func() float64 // случайное число из [0, 1) для разброса интервалов
+
+	mu        sync.Mutex
+	schedules map[string]*schedule
+
+	cancel      context.CancelFunc // останавливает опрос
+	cancelSaves context.CancelFunc // прерывает сохранение, если оно не успело завершиться
+	wg          sync.WaitGroup
+}
+
+func NewScheduler(client *Client, service ports.Service, source Source, cfg SchedulerConfig) *Scheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Minute
+	}
+	if cfg.Jitter < 0 || cfg.Jitter > 1 {
+		cfg.Jitter = 0.1
+	}
+	if cfg.RatePerSecond <= 0 {
+		cfg.RatePerSecond = 1
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = cfg.Interval
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = time.Minute
+	}
+
+	return &Scheduler{
+		client:    client,
+		service:   service,
+		source:    source,
+		cfg:       cfg,
+		clock:     systemClock{},
+		random:    rand.Float64,
+		schedules: make(map[string]*schedule),
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	// Запрос к Kaspi при остановке прерывается, а начатое сохранение данных дорабатывает до срока Shutdown
+	saveCtx, cancelSaves := context.WithCancel(context.WithoutCancel(ctx))
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.cancelSaves = cancelSaves
+	s.wg.Add(1)
+	go s.run(ctx, saveCtx)
+	slog.InfoContext(ctx, "fetch scheduler started", "interval", s.cfg.Interval, "rate", s.cfg.RatePerSecond)
+}
+
+// Shutdown останавливает планировщик и ждет завершения текущего сохранения не дольше срока ctx
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancelSaves()
+		return nil
+	case <-ctx.Done():
+		s.cancelSaves()
+		<-done
+		return fmt.Errorf("fetched offers were not saved: %w", ctx.Err())
+	}
+}
+
+func (s *Scheduler) run(ctx, saveCtx context.Context) {
+	defer s.wg.Done()
+
+	limiter := &rateLimiter{clock: s.clock, interval: time.Duration(float64(time.Second) / s.cfg.RatePerSecond)}
+
+	var refreshedAt time.Time
+	for {
+		if s.clock.Now().Sub(refreshedAt) >= s.cfg.RefreshInterval {
+			s.refresh(ctx)
+			refreshedAt = s.clock.Now()
+		}
+
+		next := s.nextDue()
+		if next == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.clock.After(time.Second):
+			}
+			continue
+		}
+
+		// Ждем ограничителя частоты запросов
+		if limiter.wait(ctx) != nil {
+			return
+		}
+
+		s.poll(ctx, saveCtx, next)
+	}
+}
+
+// refresh синхронизирует расписания со списком товаров из источника
+func (s *Scheduler) refresh(ctx context.Context) {
+	targets, err := s.source.Targets(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load fetch targets", logging.Err(err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		active[target.key()] = true
+		if existing, ok := s.schedules[target.key()]; ok {
+			existing.target = target
+			continue
+		}
+		// Новые товары распределяем по первому интервалу, чтобы не опрашивать все сразу
+		s.schedules[target.key()] = &schedule{
+			target:  target,
+			nextRun: s.clock.Now().Add(time.Duration(s.random() * float64(s.interval(target)) * s.cfg.Jitter)),
+		}
+	}
+	for key := range s.schedules {
+		if !active[key] {
+			delete(s.schedules, key)
+		}
+	}
+}
+
+// nextDue возвращает товар, которому пора обновиться: с наибольшим приоритетом, затем с самым старым сроком
+func (s *Scheduler) nextDue() *schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var due *schedule
+	for _, sch := range s.schedules {
+		if sch.nextRun.After(now) {
+			continue
+		}
+		if due == nil || sch.target.Priority > due.target.Priority ||
+			(sch.target.Priority == due.target.Priority && sch.nextRun.Before(due.nextRun)) {
+			due = sch
+		}
+	}
+	return due
+}
+
+// Lag - насколько просрочен самый давний опрос. Растет, когда ограничение частоты запросов
+// не успевает за расписанием или опрос остановился.
+func (s *Scheduler) Lag() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var lag time.Duration
+	for _, sch := range s.schedules {
+		lag = max(lag, now.Sub(sch.nextRun))
+	}
+	return lag
+}
+
+func (s *Scheduler) poll(ctx, saveCtx context.Context, sch *schedule) {
+	// Логи сервиса и репозитория при сохранении получают товар, город и рабочее пространство
+	attrs := []any{"workspace_id", sch.target.WorkspaceID, "product_id", sch.target.ProductID, "city_id", sch.target.CityID}
+	ctx, saveCtx = logging.With(ctx, attrs...), logging.With(saveCtx, attrs...)
+	err := s.fetchAndSave(ctx, saveCtx, sch.target)
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		sch.failures = 0
+		sch.nextRun = s.clock.Now().Add(s.withJitter(s.interval(sch.target)))
+		return
+	}
+
+	sch.failures++
+	delay := s.backoff(sch.failures)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		delay = statusErr.RetryAfter
+	}
+	sch.nextRun = s.clock.Now().Add(delay)
+	slog.WarnContext(ctx, "failed to fetch offers, retrying", "failures", sch.failures, "retry_in", delay.Round(time.Second), logging.Err(err))
+}
+
+func (s *Scheduler) fetchAndSave(ctx, saveCtx context.Context, target Target) error {
+	request, err := s.client.FetchOffers(ctx, target.ProductID, target.CityID)
+	if err != nil {
+		return err
+	}
+
+	if len(request.Offers.Offers) == 0 {
+		slog.InfoContext(ctx, "no offers returned")
+		return nil
+	}
+
+	_, err = s.service.SaveKaspiData(saveCtx, target.WorkspaceID, request)
+	return err
+}
+
+func (s *Scheduler) interval(target Target) time.Duration {
+	if target.Interval > 0 {
+		return target.Interval
+	}
+	return s.cfg.Interval
+}
+
+// withJitter случайно сдвигает интервал на ±Jitter, чтобы запросы не шли пачками
+func (s *Scheduler) withJitter(interval time.Duration) time.Duration {
+	offset := (s.random()*2 - 1) * s.cfg.Jitter * float64(interval)
+	return interval + time.Duration(offset)
+}
+
+// backoff - экспоненциальная задержка после ошибок: 30s, 1m, 2m, ... до MaxBackoff
+func (s *Scheduler) backoff(failures int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < failures && delay < s.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.cfg.MaxBackoff)
+}
@@ -0,0 +1,260 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+)
+
+// fakeClock - время, которое идет только при ожидании: After сразу сдвигает часы на d
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// savedRequest - вызов SaveKaspiData и время часов планировщика в этот момент
+type savedRequest struct {
+	workspaceID string
+	request     *models.KaspiDataRequest
+	at          time.Time
+}
+
+// recordingService записывает сохраненные данные; остальные методы ports.Service не вызываются
+type recordingService struct {
+	ports.Service
+
+	clock *fakeClock
+	mu    sync.Mutex
+	saved []savedRequest
+	done  chan struct{} // закрывается после limit сохранений
+	limit int
+}
+
+func (s *recordingService) SaveKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (*models.ProductAnalysis, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, savedRequest{workspaceID: workspaceID, request: request, at: s.clock.Now()})
+	if len(s.saved) == s.limit {
+		close(s.done)
+	}
+	return &models.ProductAnalysis{ProductID: request.ProductID}, nil
+}
+
+func newTestScheduler(server *fakeKaspi, service ports.Service, source Source, cfg SchedulerConfig, clock *fakeClock, random float64) *Scheduler {
+	client := NewClient(ClientConfig{URL: server.url(), CityID: "750000000", PageSize: 64})
+	s := NewScheduler(client, service, source, cfg)
+	s.clock = clock
+	s.random = func() float64 { return random }
+	return s
+}
+
+func TestSchedulerPollsTargetsWithRateLimit(t *testing.T) {
+	recorded := loadRecorded(t)
+	first, second := recorded[0], recorded[1]
+	server := newFakeKaspi(t, first, second)
+	clock := newFakeClock()
+	service := &recordingService{clock: clock, done: make(chan struct{}), limit: 4}
+
+	// Случайное число 0.5 дает нулевой сдвиг интервала, так что повторный опрос идет ровно через Interval
+	s := newTestScheduler(server, service, StaticSource{ProductIDs: []string{first.ProductID, second.ProductID}},
+		SchedulerConfig{Interval: time.Minute, Jitter: 0.2, RatePerSecond: 2}, clock, 0.5)
+	start := clock.Now()
+	s.Start(context.Background())
+	select {
+	case <-service.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("scheduler did not save 4 polls")
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	polls := make(map[string][]time.Time)
+	for i, saved := range service.saved[:4] {
+		if saved.workspaceID != models.DefaultWorkspaceID {
+			t.Errorf("poll %d saved into workspace %q", i, saved.workspaceID)
+		}
+		if saved.request.CityID != "750000000" {
+			t.Errorf("poll %d city %q, want the client default", i, saved.request.CityID)
+		}
+		polls[saved.request.ProductID] = append(polls[saved.request.ProductID], saved.at)
+
+		// Ограничение частоты: не больше 2 запросов в секунду
+		if i > 0 {
+			if gap := saved.at.Sub(service.saved[i-1].at); gap < 500*time.Millisecond {
+				t.Errorf("polls %d and %d are %s apart, want at least 500ms", i-1, i, gap)
+			}
+		}
+	}
+
+	for _, product := range []models.KaspiDataRequest{first, second} {
+		times := polls[product.ProductID]
+		if len(times) != 2 {
+			t.Fatalf("product %s polled %d times, want 2", product.ProductID, len(times))
+		}
+		// Первый опрос новых товаров разнесен на random * Interval * Jitter = 6s
+		if offset := times[0].Sub(start); offset < 6*time.Second || offset > 8*time.Second {
+			t.Errorf("product %s first polled after %s, want about 6s", product.ProductID, offset)
+		}
+		if interval := times[1].Sub(times[0]); interval < time.Minute || interval > time.Minute+2*time.Second {
+			t.Errorf("product %s polled again after %s, want about 1m", product.ProductID, interval)
+		}
+	}
+}
+
+func TestSchedulerBacksOffOnErrors(t *testing.T) {
+	recorded := loadRecorded(t)[0]
+	server := newFakeKaspi(t, recorded)
+	server.fail(
+		fakeResponse{status: http.StatusTooManyRequests, retryAfter: "120"},
+		fakeResponse{status: http.StatusInternalServerError},
+		fakeResponse{status: http.StatusBadGateway},
+		fakeResponse{status: http.StatusTooManyRequests, retryAfter: "10"},
+	)
+	clock := newFakeClock()
+	service := &recordingService{clock: clock, done: make(chan struct{}), limit: 1}
+	s := newTestScheduler(server, service, nil, SchedulerConfig{Interval: 30 * time.Minute, Jitter: 0.1, MaxBackoff: 10 * time.Minute}, clock, 0.5)
+
+	steps := []struct {
+		name         string
+		wantDelay    time.Duration
+		wantFailures int
+	}{
+		{name: "429 with Retry-After longer than backoff", wantDelay: 2 * time.Minute, wantFailures: 1},
+		{name: "500 doubles backoff", wantDelay: time.Minute, wantFailures: 2},
+		{name: "502 doubles backoff again", wantDelay: 2 * time.Minute, wantFailures: 3},
+		{name: "429 with Retry-After shorter than backoff", wantDelay: 4 * time.Minute, wantFailures: 4},
+		{name: "success resets failures", wantDelay: 30 * time.Minute, wantFailures: 0},
+	}
+	sch := &schedule{target: Target{WorkspaceID: models.DefaultWorkspaceID, ProductID: recorded.ProductID}}
+	for _, step := range steps {
+		s.poll(context.Background(), context.Background(), sch)
+		if delay := sch.nextRun.Sub(clock.Now()); delay != step.wantDelay || sch.failures != step.wantFailures {
+			t.Errorf("%s: next run in %s with %d failures, want %s and %d",
+				step.name, delay, sch.failures, step.wantDelay, step.wantFailures)
+		}
+	}
+	if len(service.saved) != 1 {
+		t.Errorf("saved %d times, want 1", len(service.saved))
+	}
+}
+
+func TestSchedulerBackoffIsCapped(t *testing.T) {
+	s := NewScheduler(nil, nil, nil, SchedulerConfig{Interval: time.Hour, MaxBackoff: 5 * time.Minute})
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, delay := range want {
+		if got := s.backoff(i + 1); got != delay {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, delay)
+		}
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	tests := []struct {
+		random float64
+		want   time.Duration
+	}{
+		{random: 0, want: 8 * time.Minute},
+		{random: 0.25, want: 9 * time.Minute},
+		{random: 0.5, want: 10 * time.Minute},
+		{random: 1, want: 12 * time.Minute},
+	}
+	for _, tt := range tests {
+		s := NewScheduler(nil, nil, nil, SchedulerConfig{Interval: 10 * time.Minute, Jitter: 0.2})
+		s.random = func() float64 { return tt.random }
+		if got := s.withJitter(10 * time.Minute); got != tt.want {
+			t.Errorf("random %v: withJitter = %s, want %s", tt.random, got, tt.want)
+		}
+	}
+}
+
+func TestSchedulerSpreadsNewTargets(t *testing.T) {
+	clock := newFakeClock()
+	source := StaticSource{ProductIDs: []string{"1"}, Cities: []string{"750000000"}}
+	s := NewScheduler(nil, nil, source, SchedulerConfig{Interval: 10 * time.Minute, Jitter: 0.5})
+	s.clock = clock
+	s.random = func() float64 { return 0.4 }
+
+	s.refresh(context.Background())
+	if s.nextDue() != nil {
+		t.Error("new target is due before its spread offset")
+	}
+	sch := s.schedules[Target{WorkspaceID: models.DefaultWorkspaceID, ProductID: "1", CityID: "750000000"}.key()]
+	if offset := sch.nextRun.Sub(clock.Now()); offset != 2*time.Minute {
+		t.Errorf("first poll in %s, want 2m (random * interval * jitter)", offset)
+	}
+
+	clock.After(2 * time.Minute)
+	if s.nextDue() != sch {
+		t.Error("target is not due after its offset")
+	}
+}
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	clock := newFakeClock()
+	limiter := &rateLimiter{clock: clock, interval: 250 * time.Millisecond}
+
+	for i := 0; i < 4; i++ {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	// Первый запрос идет сразу, остальные - через интервал
+	want := []time.Duration{250 * time.Millisecond, 250 * time.Millisecond, 250 * time.Millisecond}
+	if len(clock.waits) != len(want) {
+		t.Fatalf("waited %v, want %v", clock.waits, want)
+	}
+	for i := range want {
+		if clock.waits[i] != want[i] {
+			t.Errorf("wait %d = %s, want %s", i, clock.waits[i], want[i])
+		}
+	}
+
+	// После паузы дольше интервала ждать не нужно
+	clock.After(time.Second)
+	clock.waits = nil
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if len(clock.waits) != 0 {
+		t.Errorf("waited %v after an idle period", clock.waits)
+	}
+}
+
+func TestRateLimiterStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter := &rateLimiter{clock: systemClock{}, interval: time.Hour, next: time.Now().Add(time.Hour)}
+	if err := limiter.wait(ctx); err == nil {
+		t.Error("wait returned nil for a cancelled context")
+	}
+}
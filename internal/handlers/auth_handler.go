@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/auth"
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Authorize - middleware для mux.Router.Use: проверяет API ключ и роли маршрута.
+// Ключ передается в заголовке X-API-Key или Authorization: Bearer <ключ>.
+// Маршруты, зарегистрированные не через RegisterRoutes, доступны только admin.
+func (h *HTTPHandler) Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roles, ok := h.routeRoles[mux.CurrentRoute(r)]
+		if ok && len(roles) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !ok {
+			roles = []models.Role{models.RoleAdmin}
+		}
+
+		key := requestAPIKey(r)
+		if key == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			respondWithError(w, r, http.StatusUnauthorized, "API key is required")
+			return
+		}
+
+		apiKey, err := h.service.Authenticate(r.Context(), key)
+		if err != nil {
+			if errs.Is(err, errs.KindUnauthorized) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			respondWithServiceError(w, r, err)
+			return
+		}
+
+		if !apiKey.HasRole(roles...) {
+			respondWithError(w, r, http.StatusForbidden, fmt.Sprintf("API key %s requires role %s", apiKey.Prefix, joinRoles(roles)))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), apiKey)))
+	})
+}
+
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+func joinRoles(roles []models.Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return strings.Join(names, " or ")
+}
+
+// CreateAPIKey создает ключ; сам ключ есть только в этом ответе
+func (h *HTTPHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var request models.CreateAPIKeyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(r.Context(), workspaceID(r), &request)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, key)
+}
+
+func (h *HTTPHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListAPIKeys(r.Context(), workspaceID(r))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+func (h *HTTPHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid key ID")
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), workspaceID(r), id); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
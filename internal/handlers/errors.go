@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/logging"
+	"Mini-Quicko/internal/requestid"
+	"log/slog"
+	"net/http"
+)
+
+// ErrorResponse - единый формат ответа с ошибкой
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Коды ошибок для статусов, которых нет среди видов ошибок сервиса.
+// Статусы, совпадающие с видом ошибки сервиса, получают код этого вида: у статуса один код.
+const (
+	codeTooLarge         = "payload_too_large"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeTooManyRequests  = "too_many_requests"
+)
+
+// statusForKind - HTTP статус для вида ошибки сервиса
+func statusForKind(kind errs.Kind) int {
+	switch kind {
+	case errs.KindNotFound:
+		return http.StatusNotFound
+	case errs.KindValidation:
+		return http.StatusBadRequest
+	case errs.KindConflict:
+		return http.StatusConflict
+	case errs.KindUnavailable:
+		return http.StatusServiceUnavailable
+	case errs.KindUnauthorized:
+		return http.StatusUnauthorized
+	case errs.KindForbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// codeForStatus - код ошибки для ответа, сформированного обработчиком
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return string(errs.KindValidation)
+	case http.StatusUnauthorized:
+		return string(errs.KindUnauthorized)
+	case http.StatusForbidden:
+		return string(errs.KindForbidden)
+	case http.StatusNotFound:
+		return string(errs.KindNotFound)
+	case http.StatusMethodNotAllowed:
+		return codeMethodNotAllowed
+	case http.StatusConflict:
+		return string(errs.KindConflict)
+	case http.StatusRequestEntityTooLarge:
+		return codeTooLarge
+	case http.StatusTooManyRequests:
+		return codeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return string(errs.KindUnavailable)
+	default:
+		return string(errs.KindInternal)
+	}
+}
+
+func respondWithError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	respondWithJSON(w, status, ErrorResponse{
+		Error:     message,
+		Code:      codeForStatus(status),
+		RequestID: requestid.FromContext(r.Context()),
+	})
+}
+
+// respondWithServiceError отвечает на ошибку сервиса по ее виду. Детали внутренних ошибок
+// и недоступности (тексты ошибок БД) клиенту не отдаются, а пишутся в лог; ID запроса
+// добавляет к записи logging по контексту.
+func respondWithServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	kind := errs.KindOf(err)
+	id := requestid.FromContext(r.Context())
+	if kind == errs.KindInternal || kind == errs.KindUnavailable {
+		slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, logging.Err(err))
+	}
+
+	respondWithJSON(w, statusForKind(kind), ErrorResponse{
+		Error:     errs.Message(err),
+		Code:      string(kind),
+		RequestID: id,
+	})
+}
+
+// NotFound и MethodNotAllowed отвечают в том же формате для неизвестных маршрутов
+func (h *HTTPHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	respondWithError(w, r, http.StatusNotFound, "Route not found")
+}
+
+func (h *HTTPHandler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+}
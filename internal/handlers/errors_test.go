@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/openapi"
+
+	"github.com/gorilla/mux"
+)
+
+func TestOneErrorCodePerStatus(t *testing.T) {
+	kinds := []errs.Kind{
+		errs.KindInternal, errs.KindNotFound, errs.KindValidation, errs.KindConflict,
+		errs.KindUnavailable, errs.KindUnauthorized, errs.KindForbidden,
+	}
+	for _, kind := range kinds {
+		status := statusForKind(kind)
+		if code := codeForStatus(status); code != string(kind) {
+			t.Errorf("status %d: service error code %q, handler error code %q", status, kind, code)
+		}
+	}
+}
+
+func TestStatusForKind(t *testing.T) {
+	tests := []struct {
+		kind errs.Kind
+		want int
+	}{
+		{kind: errs.KindNotFound, want: http.StatusNotFound},
+		{kind: errs.KindValidation, want: http.StatusBadRequest},
+		{kind: errs.KindConflict, want: http.StatusConflict},
+		{kind: errs.KindUnavailable, want: http.StatusServiceUnavailable},
+		{kind: errs.KindUnauthorized, want: http.StatusUnauthorized},
+		{kind: errs.KindForbidden, want: http.StatusForbidden},
+		{kind: errs.KindInternal, want: http.StatusInternalServerError},
+		{kind: errs.Kind("unknown"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := statusForKind(tt.kind); got != tt.want {
+			t.Errorf("statusForKind(%q) = %d, want %d", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestServiceErrorHidesDriverMessage(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect 10.0.0.5:5432: connection refused")}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   errs.Kind
+		wantError  string
+	}{
+		{
+			name:       "internal",
+			err:        fmt.Errorf("failed to save product: %w", errors.New(`pq: duplicate key value violates unique constraint "product_info_pkey"`)),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   errs.KindInternal,
+			wantError:  "Internal server error",
+		},
+		{
+			name:       "unavailable",
+			err:        errs.Unavailable(fmt.Errorf("query: %w", refused), "Storage is temporarily unavailable"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   errs.KindUnavailable,
+			wantError:  "Storage is temporarily unavailable",
+		},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		respondWithServiceError(w, httptest.NewRequest("GET", "/products/p1/analyze", nil), tt.err)
+
+		var response ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if w.Code != tt.wantStatus || response.Code != string(tt.wantCode) || response.Error != tt.wantError {
+			t.Errorf("%s: %d %+v, want %d %q %q", tt.name, w.Code, response, tt.wantStatus, tt.wantCode, tt.wantError)
+		}
+		for _, leak := range []string{"pq:", "product_info_pkey", "10.0.0.5", "connection refused"} {
+			if strings.Contains(w.Body.String(), leak) {
+				t.Errorf("%s: response %s contains %q", tt.name, w.Body, leak)
+			}
+		}
+	}
+}
+
+func TestBadRequestCodes(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHTTPHandler(fakeService{}, Config{})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.Use(handler.Authorize)
+	router.Use(openapi.NewValidator(doc, true, false).Middleware)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{name: "handler", method: "POST", path: "/products/analyze", body: `{}`},
+		{name: "spec validator", method: "GET", path: "/products/121806358/analyze?format=pdf"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		r.Header.Set("X-API-Key", testAPIKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		var response ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if w.Code != http.StatusBadRequest || response.Code != string(errs.KindValidation) {
+			t.Errorf("%s: status %d code %q, want 400 %q", tt.name, w.Code, response.Code, errs.KindValidation)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	respondWithServiceError(w, httptest.NewRequest("GET", "/", nil), errs.Validation("unknown strategy %q", "max"))
+	var response ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusBadRequest || response.Code != string(errs.KindValidation) {
+		t.Errorf("service: status %d code %q, want 400 %q", w.Code, response.Code, errs.KindValidation)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/export"
+	"Mini-Quicko/internal/logging"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// spreadsheetFormat определяет табличный формат ответа по параметру format или заголовку Accept.
+// ok=false означает обычный JSON.
+func spreadsheetFormat(r *http.Request) (format export.Format, ok bool, err error) {
+	if value := r.URL.Query().Get("format"); value != "" {
+		if strings.EqualFold(value, "json") {
+			return "", false, nil
+		}
+		format, err = export.ParseFormat(value)
+		return format, err == nil, err
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accept))
+		switch mediaType {
+		case "application/json":
+			return "", false, nil
+		case "text/csv":
+			return export.FormatCSV, true, nil
+		case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+			return export.FormatXLSX, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// spreadsheetLang - язык заголовков: параметр lang, затем Accept-Language
+func spreadsheetLang(r *http.Request) export.Lang {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return export.ParseLang(lang)
+	}
+	return export.ParseLang(r.Header.Get("Accept-Language"))
+}
+
+// respondWithSpreadsheet пишет таблицу потоково: write передает строки в emit по мере получения.
+// Заголовки ответа отправляются с первой строкой, поэтому ошибка до нее возвращается обычным JSON.
+func respondWithSpreadsheet(w http.ResponseWriter, r *http.Request, format export.Format, filename string,
+	columns []export.Column, write func(emit func(values ...interface{}) error) error) {
+
+	var writer export.Writer
+	start := func() error {
+		if writer != nil {
+			return nil
+		}
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+		w.WriteHeader(http.StatusOK)
+
+		var err error
+		writer, err = export.NewWriter(w, format, spreadsheetLang(r), filename, columns)
+		return err
+	}
+
+	err := write(func(values ...interface{}) error {
+		if err := start(); err != nil {
+			return err
+		}
+		return writer.WriteRow(values...)
+	})
+	if err != nil && writer == nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	if err == nil {
+		// Пустая таблица - только строка заголовков
+		err = start()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to write export", "format", format, "filename", filename, logging.Err(err))
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/core/models"
+	"net/http"
+)
+
+// Drain переводит обработчик в режим остановки: /readyz отвечает 503, чтобы балансировщик
+// (Kubernetes Service) перестал направлять запросы, пока сервер дорабатывает текущие
+func (h *HTTPHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// HealthCheck - подробная проверка компонентов для Docker healthcheck и мониторинга.
+// 503 - отказ критичного компонента (БД, миграции), degraded отвечает 200.
+func (h *HTTPHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	report := h.config.Health.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status == models.HealthUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, status, report)
+}
+
+// Livez - проверка живости для livenessProbe: процесс отвечает на запросы. Зависимости не проверяются,
+// чтобы недоступность БД не приводила к перезапуску подов.
+func (h *HTTPHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz - проверка готовности для readinessProbe: сервер не останавливается и БД доступна
+func (h *HTTPHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		respondWithError(w, r, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+	if err := h.service.HealthCheck(r.Context()); err != nil {
+		respondWithError(w, r, http.StatusServiceUnavailable, "Database is unavailable")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/importer"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Максимальный размер импортируемой таблицы: XLSX читается целиком, так как это zip-архив
+const maxImportSize = 32 << 20
+
+// ImportTable принимает CSV или XLSX с офферами конкурентов (тело запроса - сам файл).
+// Формат задается параметром format или Content-Type, колонки - параметром map
+// ("price=Цена,merchantId=ID магазина") поверх import.columns из конфигурации.
+func (h *HTTPHandler) ImportTable(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format, ok := importer.DetectFormat(query.Get("format"))
+	if !ok {
+		format, ok = importer.DetectFormat(r.Header.Get("Content-Type"))
+	}
+	if !ok {
+		respondWithError(w, r, http.StatusBadRequest, "Unsupported format: use format=csv|xlsx or a text/csv or xlsx Content-Type")
+		return
+	}
+
+	mapping := h.config.ImportColumns
+	if value := query.Get("map"); value != "" {
+		override, err := importer.ParseMapping(value)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		mapping = mapping.Merge(override)
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, r, http.StatusRequestEntityTooLarge, "File is too large")
+			return
+		}
+		respondWithError(w, r, http.StatusBadRequest, "Failed to read body")
+		return
+	}
+
+	rows, err := importer.ReadTable(data, format)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	summary, err := importer.ImportTable(r.Context(), h.service, workspaceID(r), rows, mapping, query.Get("city"))
+	if err != nil {
+		if summary == nil {
+			respondWithError(w, r, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/logging"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	ingestStatusOK    = "ok"
+	ingestStatusError = "error"
+
+	// Максимальный размер одной строки NDJSON
+	maxIngestLineSize = 16 << 20
+)
+
+// IngestKaspiData принимает NDJSON (по одному KaspiDataRequest в строке, опционально в gzip)
+// и обрабатывает строки по одной, не загружая весь payload в память.
+func (h *HTTPHandler) IngestKaspiData(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	if isGzipRequest(r) {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid gzip body")
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxIngestLineSize)
+
+	summary := models.IngestSummary{Results: []models.IngestResult{}}
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		result := h.ingestLine(r, line, raw)
+		summary.Lines++
+		if result.Status == ingestStatusOK {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, result)
+
+		if r.Context().Err() != nil {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		summary.Failed++
+		summary.Results = append(summary.Results, models.IngestResult{
+			Line:   line + 1,
+			Status: ingestStatusError,
+			Error:  "Failed to read body: " + err.Error(),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+func (h *HTTPHandler) ingestLine(r *http.Request, line int, raw string) models.IngestResult {
+	result := models.IngestResult{Line: line, Status: ingestStatusError}
+
+	var request models.KaspiDataRequest
+	if err := json.Unmarshal([]byte(raw), &request); err != nil {
+		result.Error = "Invalid JSON: " + err.Error()
+		return result
+	}
+	result.ProductID = request.ProductID
+	result.Offers = len(request.Offers.Offers)
+
+	if message := validateKaspiDataRequest(&request); message != "" {
+		result.Error = message
+		return result
+	}
+
+	analysis, err := h.service.SaveKaspiData(r.Context(), workspaceID(r), &request)
+	if err != nil {
+		result.Error = errs.Message(err)
+		logIngestError(r, request.ProductID, err)
+		return result
+	}
+
+	result.Status = ingestStatusOK
+	result.Quarantined = len(analysis.QuarantinedOffers)
+	result.MinPrice = analysis.MinPrice
+	result.OptimalPrice = analysis.OptimalPrice
+	return result
+}
+
+// logIngestError пишет в лог детали ошибки, которые не попадают в ответ клиенту
+func logIngestError(r *http.Request, productID string, err error) {
+	if kind := errs.KindOf(err); kind == errs.KindInternal || kind == errs.KindUnavailable {
+		slog.ErrorContext(r.Context(), "ingestion failed", "product_id", productID, logging.Err(err))
+	}
+}
+
+func isGzipRequest(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") ||
+		strings.HasPrefix(r.Header.Get("Content-Type"), "application/gzip")
+}
@@ -3,37 +3,90 @@ package handlers
 import (
 	"Mini-Quicko/internal/core/models"
 	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/export"
+	"Mini-Quicko/internal/health"
+	"Mini-Quicko/internal/importer"
+	"Mini-Quicko/internal/ratelimit"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type HTTPHandler struct {
-	service ports.Service
+	service    ports.Service
+	config     Config
+	routeRoles map[*mux.Route][]models.Role
+	draining   atomic.Bool // сервер останавливается, /readyz отвечает 503
 }
 
-func NewHTTPHandler(service ports.Service) *HTTPHandler {
+// Config - настройки обработчиков
+type Config struct {
+	ImportColumns  importer.Mapping   // сопоставление колонок CSV/XLSX по умолчанию
+	RateLimiter    *ratelimit.Limiter // nil - без ограничения частоты запросов
+	IPRateLimiter  *ratelimit.Limiter // ограничение по IP до проверки API ключа, nil - без него
+	TrustedProxies int                // число своих прокси перед сервером, 0 - X-Forwarded-For не учитывается
+	Health         *health.Checker    // проверки /health, nil - только доступность БД
+}
+
+func NewHTTPHandler(service ports.Service, config Config) *HTTPHandler {
+	if config.Health == nil {
+		config.Health = health.NewChecker(0, health.Thresholds{}, health.Database(service.HealthCheck))
+	}
 	return &HTTPHandler{
-		service: service,
+		service:    service,
+		config:     config,
+		routeRoles: make(map[*mux.Route][]models.Role),
 	}
 }
 
 func (h *HTTPHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/health", h.HealthCheck).Methods("GET")
-	router.HandleFunc("/products/{productId}/analyze", h.AnalyzeProduct).Methods("GET")
-	router.HandleFunc("/products/{productId}/history", h.GetPriceHistory).Methods("GET")
-	router.HandleFunc("/products/{productId}/info", h.GetProductInfo).Methods("GET")
-	router.HandleFunc("/products/save-kaspi-data", h.SaveKaspiData).Methods("POST")
-}
+	// Служебные маршруты открыты, остальные требуют API ключ с одной из ролей (admin дает все)
+	h.handle(router, "GET", "/health", h.HealthCheck)
+	h.handle(router, "GET", "/livez", h.Livez)
+	h.handle(router, "GET", "/readyz", h.Readyz)
+	h.handle(router, "GET", "/openapi.json", h.GetOpenAPI)
+	h.handle(router, "GET", "/docs", h.GetDocs)
 
-func (h *HTTPHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	if err := h.service.HealthCheck(r.Context()); err != nil {
-		respondWithError(w, http.StatusServiceUnavailable, "Service unavailable")
-		return
-	}
+	h.handle(router, "POST", "/products/analyze", h.AnalyzeProducts, models.RoleRead)
+	h.handle(router, "GET", "/products/{productId}/analyze", h.AnalyzeProduct, models.RoleRead)
+	h.handle(router, "GET", "/products/{productId}/history", h.GetPriceHistory, models.RoleRead)
+	h.handle(router, "GET", "/products/{productId}/info", h.GetProductInfo, models.RoleRead)
+	h.handle(router, "GET", "/products/{productId}/backtest", h.Backtest, models.RoleRead)
+	h.handle(router, "GET", "/products/{productId}/cities", h.CompareCities, models.RoleRead)
+	h.handle(router, "GET", "/quarantine", h.GetQuarantinedOffers, models.RoleRead)
+
+	h.handle(router, "POST", "/products/save-kaspi-data", h.SaveKaspiData, models.RoleIngest)
+	h.handle(router, "POST", "/products/ingest", h.IngestKaspiData, models.RoleIngest)
+	h.handle(router, "POST", "/products/import", h.ImportTable, models.RoleIngest)
+	h.handle(router, "GET", "/jobs/{id:[0-9]+}", h.GetJob, models.RoleIngest, models.RoleRead)
+
+	h.handle(router, "GET", "/watchlist", h.GetWatchlist, models.RoleRead)
+	h.handle(router, "GET", "/watchlist/{productId}", h.GetWatchlistEntry, models.RoleRead)
+	h.handle(router, "POST", "/watchlist", h.SaveWatchlistEntry, models.RoleAdmin)
+	h.handle(router, "DELETE", "/watchlist/{productId}", h.DeleteWatchlistEntry, models.RoleAdmin)
 
-	respondWithJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+	h.handle(router, "GET", "/workspace", h.GetWorkspace, models.RoleIngest, models.RoleRead)
+	h.handle(router, "PUT", "/workspace", h.UpdateWorkspace, models.RoleAdmin)
+
+	h.handle(router, "POST", "/api-keys", h.CreateAPIKey, models.RoleAdmin)
+	h.handle(router, "GET", "/api-keys", h.ListAPIKeys, models.RoleAdmin)
+	h.handle(router, "DELETE", "/api-keys/{id:[0-9]+}", h.RevokeAPIKey, models.RoleAdmin)
+
+	router.NotFoundHandler = http.HandlerFunc(h.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(h.MethodNotAllowed)
+}
+
+// handle регистрирует маршрут и запоминает роли, которые проверяет Authorize.
+// Маршрут без ролей доступен без ключа.
+func (h *HTTPHandler) handle(router *mux.Router, method, path string, handler http.HandlerFunc, roles ...models.Role) {
+	route := router.HandleFunc(path, handler).Methods(method)
+	h.routeRoles[route] = roles
 }
 
 func (h *HTTPHandler) AnalyzeProduct(w http.ResponseWriter, r *http.Request) {
@@ -41,31 +94,116 @@ func (h *HTTPHandler) AnalyzeProduct(w http.ResponseWriter, r *http.Request) {
 	productID := vars["productId"]
 
 	if productID == "" {
-		respondWithError(w, http.StatusBadRequest, "Product ID is required")
+		respondWithError(w, r, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	format, spreadsheet, err := spreadsheetFormat(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	analysis, err := h.service.AnalyzeProduct(r.Context(), productID)
+	analysis, err := h.service.AnalyzeProduct(r.Context(), workspaceID(r), productID, r.URL.Query().Get("city"))
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	if spreadsheet {
+		respondWithSpreadsheet(w, r, format, "analysis-"+productID, export.AnalysisColumns, func(emit func(...interface{}) error) error {
+			for _, row := range export.AnalysisRows(analysis) {
+				if err := emit(row...); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, analysis)
 }
 
+func (h *HTTPHandler) AnalyzeProducts(w http.ResponseWriter, r *http.Request) {
+	var request models.BulkAnalysisRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(request.ProductIDs) == 0 && request.Filter == nil {
+		respondWithError(w, r, http.StatusBadRequest, "Either product_ids or filter is required")
+		return
+	}
+
+	format, spreadsheet, err := spreadsheetFormat(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.AnalyzeProducts(r.Context(), workspaceID(r), &request)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	if spreadsheet {
+		respondWithSpreadsheet(w, r, format, "bulk-analysis", export.BulkColumns, func(emit func(...interface{}) error) error {
+			for _, product := range result.Results {
+				if err := emit(export.BulkRow(product)...); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
 func (h *HTTPHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	productID := vars["productId"]
 
 	if productID == "" {
-		respondWithError(w, http.StatusBadRequest, "Product ID is required")
+		respondWithError(w, r, http.StatusBadRequest, "Product ID is required")
 		return
 	}
 
-	history, err := h.service.GetPriceHistory(r.Context(), productID)
+	format, spreadsheet, err := spreadsheetFormat(r)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// В таблицу выгружается вся история за период (from/to), а не последние записи
+	if spreadsheet {
+		query := r.URL.Query()
+		filter := models.HistoryFilter{ProductIDs: []string{productID}, CityID: query.Get("city")}
+		if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid from: expected RFC3339 or YYYY-MM-DD")
+			return
+		}
+		if filter.To, err = parseRangeEnd(query.Get("to")); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid to: expected RFC3339 or YYYY-MM-DD")
+			return
+		}
+
+		respondWithSpreadsheet(w, r, format, "history-"+productID, export.HistoryColumns, func(emit func(...interface{}) error) error {
+			return h.service.ExportPriceHistory(r.Context(), workspaceID(r), filter, func(history models.PriceHistory) error {
+				return emit(export.HistoryRow(history)...)
+			})
+		})
+		return
+	}
+
+	history, err := h.service.GetPriceHistory(r.Context(), workspaceID(r), productID, r.URL.Query().Get("city"))
+	if err != nil {
+		respondWithServiceError(w, r, err)
 		return
 	}
 
@@ -77,44 +215,186 @@ func (h *HTTPHandler) GetProductInfo(w http.ResponseWriter, r *http.Request) {
 	productID := vars["productId"]
 
 	if productID == "" {
-		respondWithError(w, http.StatusBadRequest, "Product ID is required")
+		respondWithError(w, r, http.StatusBadRequest, "Product ID is required")
 		return
 	}
 
-	info, err := h.service.GetProductInfo(r.Context(), productID)
+	info, err := h.service.GetProductInfo(r.Context(), workspaceID(r), productID, r.URL.Query().Get("city"))
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, info)
 }
 
+func (h *HTTPHandler) CompareCities(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID := vars["productId"]
+
+	if productID == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	comparison, err := h.service.CompareCities(r.Context(), workspaceID(r), productID)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, comparison)
+}
+
 func (h *HTTPHandler) SaveKaspiData(w http.ResponseWriter, r *http.Request) {
 	var request models.KaspiDataRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if request.ProductID == "" {
-		respondWithError(w, http.StatusBadRequest, "Product ID is required")
+	if message := validateKaspiDataRequest(&request); message != "" {
+		respondWithError(w, r, http.StatusBadRequest, message)
 		return
 	}
 
-	if len(request.Offers.Offers) == 0 {
-		respondWithError(w, http.StatusBadRequest, "No offers provided")
+	// С sync=true данные обрабатываются в запросе и ответом приходит анализ
+	if sync, _ := strconv.ParseBool(r.URL.Query().Get("sync")); sync {
+		analysis, err := h.service.SaveKaspiData(r.Context(), workspaceID(r), &request)
+		if err != nil {
+			respondWithServiceError(w, r, err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusCreated, analysis)
+		return
+	}
+
+	// По умолчанию данные ставятся в очередь и обрабатываются воркерами
+	job, err := h.service.EnqueueKaspiData(r.Context(), workspaceID(r), &request)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/jobs/%d", job.ID))
+	respondWithJSON(w, http.StatusAccepted, job)
+}
+
+func (h *HTTPHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.service.GetJob(r.Context(), workspaceID(r), id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+func (h *HTTPHandler) GetQuarantinedOffers(w http.ResponseWriter, r *http.Request) {
+	offers, err := h.service.GetQuarantinedOffers(r.Context(), workspaceID(r), r.URL.Query().Get("product_id"))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, offers)
+}
+
+func (h *HTTPHandler) Backtest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID := vars["productId"]
+
+	if productID == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Product ID is required")
 		return
 	}
 
-	analysis, err := h.service.SaveKaspiData(r.Context(), &request)
+	query := r.URL.Query()
+	request := models.BacktestRequest{
+		ProductID:  productID,
+		CityID:     query.Get("city"),
+		MerchantID: query.Get("merchant_id"),
+	}
+
+	if strategies := query.Get("strategies"); strategies != "" {
+		request.Strategies = strings.Split(strategies, ",")
+	}
+
+	if unitCost := query.Get("unit_cost"); unitCost != "" {
+		value, err := strconv.ParseFloat(unitCost, 64)
+		if err != nil || value < 0 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid unit_cost")
+			return
+		}
+		request.UnitCost = value
+	}
+
+	var err error
+	if request.From, err = parseTimeParam(query.Get("from")); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid from: expected RFC3339 or YYYY-MM-DD")
+		return
+	}
+	if request.To, err = parseRangeEnd(query.Get("to")); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid to: expected RFC3339 or YYYY-MM-DD")
+		return
+	}
+
+	report, err := h.service.Backtest(r.Context(), workspaceID(r), &request)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, analysis)
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// parseTimeParam разбирает время в формате RFC3339 или дату YYYY-MM-DD, пустая строка дает нулевое время
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// parseRangeEnd разбирает конец периода: дата без времени включает весь день, то есть период
+// заканчивается до начала следующих суток. Запросы к БД сравнивают timestamp <= to, поэтому
+// берется последняя микросекунда дня - точность timestamp в Postgres.
+func parseRangeEnd(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+}
+
+// validateKaspiDataRequest возвращает текст ошибки или пустую строку, если запрос корректен
+func validateKaspiDataRequest(request *models.KaspiDataRequest) string {
+	if request.ProductID == "" {
+		return "Product ID is required"
+	}
+
+	if len(request.Offers.Offers) == 0 {
+		return "No offers provided"
+	}
+
+	return ""
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -128,7 +408,3 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 	w.Write(response)
 }
-
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
-}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+
+	"github.com/gorilla/mux"
+)
+
+func TestParseRangeEnd(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "2024-05-31T12:30:00Z", want: time.Date(2024, 5, 31, 12, 30, 0, 0, time.UTC)},
+		{value: "2024-05-31", want: time.Date(2024, 5, 31, 23, 59, 59, 999999000, time.UTC)},
+		{value: "2024-12-31", want: time.Date(2024, 12, 31, 23, 59, 59, 999999000, time.UTC)},
+		{value: "2024-13-45", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRangeEnd(tt.value)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("parseRangeEnd(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// backtestService запоминает запрос бэктеста
+type backtestService struct {
+	fakeService
+	request *models.BacktestRequest
+}
+
+func (s *backtestService) Backtest(ctx context.Context, workspaceID string, request *models.BacktestRequest) (*models.BacktestReport, error) {
+	s.request = request
+	return s.fakeService.Backtest(ctx, workspaceID, request)
+}
+
+func TestBacktestDateOnlyRangeIncludesLastDay(t *testing.T) {
+	service := &backtestService{}
+	router := mux.NewRouter()
+	NewHTTPHandler(service, Config{}).RegisterRoutes(router)
+
+	r := httptest.NewRequest("GET", "/products/121806358/backtest?from=2024-05-01&to=2024-05-31", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	// Снапшот вечером 31 мая входит в период, полночь 1 июня - уже нет
+	lastEvening := time.Date(2024, 5, 31, 21, 0, 0, 0, time.UTC)
+	nextDay := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	if service.request.To.Before(lastEvening) || !service.request.To.Before(nextDay) {
+		t.Errorf("to = %v, want the end of 2024-05-31", service.request.To)
+	}
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC); !service.request.From.Equal(want) {
+		t.Errorf("from = %v, want %v", service.request.From, want)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/auth"
+	"Mini-Quicko/internal/ratelimit"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RateLimitIP - middleware для mux.Router.Use: ограничивает частоту запросов с одного IP адреса.
+// Ставится до Authorize, чтобы перебор и поток запросов с неверными ключами не доходили до БД.
+func (h *HTTPHandler) RateLimitIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.limit(w, r, next, h.config.IPRateLimiter, "auth|"+h.clientIP(r))
+	})
+}
+
+// RateLimit - middleware для mux.Router.Use: ограничивает частоту запросов и суточные квоты клиента.
+// Клиент - API ключ, а для запросов без ключа - IP адрес, поэтому middleware ставится после Authorize.
+func (h *HTTPHandler) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.limit(w, r, next, h.config.RateLimiter, h.rateLimitClient(r))
+	})
+}
+
+func (h *HTTPHandler) limit(w http.ResponseWriter, r *http.Request, next http.Handler, limiter *ratelimit.Limiter, client string) {
+	route := mux.CurrentRoute(r)
+	if limiter == nil || route == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	decision := limiter.Allow(r.Context(), client, r.Method, path, time.Now())
+	if decision.Daily > 0 {
+		w.Header().Set("X-Quota-Limit", strconv.Itoa(decision.Daily))
+		w.Header().Set("X-Quota-Remaining", strconv.Itoa(decision.Daily-decision.Used))
+	}
+	if decision.Allowed {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(decision.RetryAfterSeconds()))
+	message := "Too many requests"
+	if decision.Reason == "quota" {
+		message = fmt.Sprintf("Daily quota of %d requests is exhausted", decision.Daily)
+	}
+	respondWithError(w, r, http.StatusTooManyRequests, message)
+}
+
+// rateLimitClient - ключ клиента для ограничений: ID API ключа или IP адрес
+func (h *HTTPHandler) rateLimitClient(r *http.Request) string {
+	if key := auth.FromContext(r.Context()); key != nil {
+		return "key:" + strconv.FormatInt(key.ID, 10)
+	}
+	return h.clientIP(r)
+}
+
+// clientIP - IP адрес клиента. За TrustedProxies своими прокси адрес клиента - первый справа
+// адрес X-Forwarded-For, который добавил не наш прокси: адреса левее клиент мог подставить сам.
+func (h *HTTPHandler) clientIP(r *http.Request) string {
+	if proxies := h.config.TrustedProxies; proxies > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(header, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		// Адрес клиента добавил самый дальний наш прокси. Если адресов меньше, чем прокси,
+		// запрос прошел не через все из них, и берется самый левый адрес.
+		if i := max(0, len(hops)-proxies); i < len(hops) && net.ParseIP(hops[i]) != nil {
+			return "ip:" + hops[i]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
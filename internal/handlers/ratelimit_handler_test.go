@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Mini-Quicko/internal/ratelimit"
+
+	"github.com/gorilla/mux"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		proxies   int
+		forwarded []string
+		want      string
+	}{
+		{name: "proxy not trusted", forwarded: []string{"203.0.113.7"}, want: "ip:192.0.2.1"},
+		{name: "no header", proxies: 1, want: "ip:192.0.2.1"},
+		{name: "one proxy", proxies: 1, forwarded: []string{"203.0.113.7"}, want: "ip:203.0.113.7"},
+		{name: "spoofed hops are ignored", proxies: 1, forwarded: []string{"198.51.100.9, 203.0.113.7"}, want: "ip:203.0.113.7"},
+		{name: "two proxies", proxies: 2, forwarded: []string{"198.51.100.9, 203.0.113.7, 10.0.0.2"}, want: "ip:203.0.113.7"},
+		{name: "repeated headers", proxies: 2, forwarded: []string{"198.51.100.9", "203.0.113.7, 10.0.0.2"}, want: "ip:203.0.113.7"},
+		{name: "fewer hops than proxies", proxies: 3, forwarded: []string{"203.0.113.7, 10.0.0.2"}, want: "ip:203.0.113.7"},
+		{name: "not an IP", proxies: 1, forwarded: []string{"unknown"}, want: "ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		h := NewHTTPHandler(fakeService{}, Config{TrustedProxies: tt.proxies})
+		r := httptest.NewRequest("GET", "/watchlist", nil)
+		r.RemoteAddr = "192.0.2.1:54321"
+		for _, value := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+		if got := h.clientIP(r); got != tt.want {
+			t.Errorf("%s: clientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitIPRunsBeforeAuthorize(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	handler := NewHTTPHandler(fakeService{}, Config{
+		IPRateLimiter: ratelimit.NewLimiter(store, ratelimit.Limit{Rate: 0.001, Burst: 2}, nil),
+		RateLimiter:   ratelimit.NewLimiter(store, ratelimit.Limit{Rate: 0.001, Burst: 3}, nil),
+	})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.Use(handler.RateLimitIP)
+	router.Use(handler.Authorize)
+	router.Use(handler.RateLimit)
+
+	request := func(ip, key string) int {
+		r := httptest.NewRequest("GET", "/watchlist", nil)
+		r.RemoteAddr = ip + ":54321"
+		r.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Перебор ключей с одного IP упирается в ограничение по IP, не доходя до проверки ключа
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, status := range want {
+		if got := request("198.51.100.9", "guess"); got != status {
+			t.Errorf("invalid key request %d: status %d, want %d", i+1, got, status)
+		}
+	}
+
+	// Ограничение по ключу общее для всех IP, с которых приходит ключ
+	want = []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i, status := range want {
+		ip := []string{"203.0.113.1", "203.0.113.2"}[i%2]
+		if got := request(ip, testAPIKey); got != status {
+			t.Errorf("valid key request %d from %s: status %d, want %d", i+1, ip, got, status)
+		}
+	}
+}
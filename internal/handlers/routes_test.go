@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/openapi"
+
+	"github.com/gorilla/mux"
+)
+
+const testAPIKey = "mq_test_key"
+
+// missingProductID - товар, которого нет у fakeService: проверяет документированный ответ 404
+const missingProductID = "404404"
+
+var testTime = time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+
+// fakeService отвечает правдоподобными данными, чтобы ответы обработчиков можно было проверить по спецификации
+type fakeService struct {
+	ports.Service
+}
+
+func (fakeService) AnalyzeProduct(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductAnalysis, error) {
+	if productID == missingProductID {
+		return nil, errs.NotFound("no data found for product %s", productID)
+	}
+	return testAnalysis(productID), nil
+}
+
+func (fakeService) AnalyzeProducts(ctx context.Context, workspaceID string, request *models.BulkAnalysisRequest) (*models.BulkAnalysisResult, error) {
+	result := &models.BulkAnalysisResult{Summary: models.PortfolioSummary{MerchantID: request.MerchantID}}
+	for _, productID := range request.ProductIDs {
+		product := models.ProductAnalysisResult{ProductID: productID, Tags: []string{"tv"}}
+		if productID == missingProductID {
+			product.Error = "no data found for product " + productID
+			result.Summary.Failed++
+		} else {
+			product.Analysis = testAnalysis(productID)
+			product.Present = true
+			product.RevenueAtRisk = 77035720
+			result.Summary.Analyzed++
+			result.Summary.Present++
+		}
+		result.Results = append(result.Results, product)
+	}
+	result.Summary.Products = len(result.Results)
+	return result, nil
+}
+
+func (fakeService) GetPriceHistory(ctx context.Context, workspaceID, productID, cityID string) ([]models.PriceHistory, error) {
+	return []models.PriceHistory{{ID: 1, ProductID: productID, CityID: models.DefaultCityID, SellerID: "30358551", Price: 179990, Timestamp: testTime}}, nil
+}
+
+func (fakeService) GetProductInfo(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductInfo, error) {
+	return &models.ProductInfo{ProductID: productID, CityID: models.DefaultCityID, Sellers: testSellers(), Timestamp: testTime}, nil
+}
+
+func (fakeService) CompareCities(ctx context.Context, workspaceID, productID string) (*models.CityComparison, error) {
+	return &models.CityComparison{
+		ProductID: productID,
+		Cities: []models.CityPriceSummary{
+			{CityID: "750000000", MinPrice: 179990, MedianPrice: 184990, AvgPrice: 185000, OptimalPrice: 179989, Sellers: 3, Timestamp: testTime},
+			{CityID: "710000000", MinPrice: 181990, MedianPrice: 186990, AvgPrice: 187000, OptimalPrice: 181989, Sellers: 2, Timestamp: testTime},
+		},
+		CheapestCityID:      "750000000",
+		MostExpensiveCityID: "710000000",
+		CitySpreadPercent:   1.11,
+	}, nil
+}
+
+func (fakeService) SaveKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (*models.ProductAnalysis, error) {
+	return testAnalysis(request.ProductID), nil
+}
+
+func (fakeService) EnqueueKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (*models.Job, error) {
+	return &models.Job{ID: 1, ProductID: request.ProductID, Status: models.JobStatusQueued, MaxAttempts: 5, RunAt: testTime, CreatedAt: testTime, UpdatedAt: testTime}, nil
+}
+
+func (fakeService) GetJob(ctx context.Context, workspaceID string, id int64) (*models.Job, error) {
+	finished := testTime.Add(time.Second)
+	return &models.Job{
+		ID: id, ProductID: "121806358", Status: models.JobStatusSucceeded, Attempts: 1, MaxAttempts: 5,
+		Result: testAnalysis("121806358"), RunAt: testTime, CreatedAt: testTime, UpdatedAt: finished, FinishedAt: &finished,
+	}, nil
+}
+
+func (fakeService) Backtest(ctx context.Context, workspaceID string, request *models.BacktestRequest) (*models.BacktestReport, error) {
+	return &models.BacktestReport{
+		ProductID: request.ProductID, CityID: models.DefaultCityID, Snapshots: 12, From: testTime.AddDate(0, 0, -7), To: testTime,
+		Results: []models.StrategyResult{{Strategy: "min", CheapestRate: 0.92, AvgPrice: 179990, AvgPremiumPercent: -0.5, AvgMarginPercent: 12, PriceChanges: 4, EvaluatedSnapshots: 12}},
+	}, nil
+}
+
+func (fakeService) GetQuarantinedOffers(ctx context.Context, workspaceID, productID string) ([]models.QuarantinedOffer, error) {
+	return []models.QuarantinedOffer{testQuarantinedOffer(productID)}, nil
+}
+
+func (fakeService) SaveWatchlistEntry(ctx context.Context, workspaceID string, entry *models.WatchlistEntry) error {
+	entry.CreatedAt, entry.UpdatedAt = testTime, testTime
+	return nil
+}
+
+func (fakeService) GetWatchlist(ctx context.Context, workspaceID string, tags []string) ([]models.WatchlistEntry, error) {
+	return []models.WatchlistEntry{testWatchlistEntry("121806358")}, nil
+}
+
+func (fakeService) GetWatchlistEntry(ctx context.Context, workspaceID, productID string) (*models.WatchlistEntry, error) {
+	entry := testWatchlistEntry(productID)
+	return &entry, nil
+}
+
+func (fakeService) DeleteWatchlistEntry(ctx context.Context, workspaceID, productID string) error {
+	return nil
+}
+
+func (fakeService) GetWorkspace(ctx context.Context, id string) (*models.Workspace, error) {
+	return &models.Workspace{ID: id, Name: "Default", OwnMerchantID: "30358551", CreatedAt: testTime}, nil
+}
+
+func (fakeService) UpdateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	workspace.CreatedAt = testTime
+	return nil
+}
+
+func (fakeService) CreateAPIKey(ctx context.Context, workspaceID string, request *models.CreateAPIKeyRequest) (*models.NewAPIKey, error) {
+	key := testAPIKeyRecord(workspaceID)
+	key.Name, key.Roles = request.Name, request.Roles
+	return &models.NewAPIKey{APIKey: key, Key: "mq_0123456789abcdef"}, nil
+}
+
+func (fakeService) ListAPIKeys(ctx context.Context, workspaceID string) ([]models.APIKey, error) {
+	return []models.APIKey{testAPIKeyRecord(workspaceID)}, nil
+}
+
+func (fakeService) RevokeAPIKey(ctx context.Context, workspaceID string, id int64) error {
+	return nil
+}
+
+func (fakeService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if key != testAPIKey {
+		return nil, errs.Unauthorized("invalid API key")
+	}
+	apiKey := testAPIKeyRecord(models.DefaultWorkspaceID)
+	return &apiKey, nil
+}
+
+func (fakeService) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func testSellers() []models.Seller {
+	return []models.Seller{
+		{ID: "30358551", Name: "Mechta", Price: 179990, Rating: 4.9, Reviews: 1200, Purchases: 428, SKU: "55421", Segment: 80, Position: 1, DeliveryType: "EXPRESS", DeliveryDuration: "TODAY", KaspiDelivery: true},
+		{ID: "15503068", Name: "Sulpak", Price: 184990, Rating: 4.8, Reviews: 900, Purchases: 120, SKU: "1001", Segment: 60, Position: 2, DeliveryType: "DELIVERY", DeliveryDuration: "TOMORROW"},
+		{ID: "11427005", Name: "Dump", Price: 99990, Rating: 3.1, Reviews: 2, Position: 3},
+	}
+}
+
+func testAnalysis(productID string) *models.ProductAnalysis {
+	sellers := testSellers()
+	return &models.ProductAnalysis{
+		ProductID: productID, CityID: models.DefaultCityID,
+		MinPrice: 179990, MaxPrice: 184990, AvgPrice: 182490, WeightedAvgPrice: 181100, MedianPrice: 182490,
+		P10Price: 180490, P25Price: 181240, P75Price: 183740, P90Price: 184490, StdDev: 2500, IQR: 2500,
+		PriceSpreadPercent: 2.78, OptimalPrice: 179989,
+		DumpingSellers: sellers[2:],
+		NoiseSellers:   []models.Seller{},
+		Sellers:        sellers,
+		Competitors: []models.RankedSeller{
+			{Seller: sellers[0], Rank: 1, Score: 0.91, BayesianRating: 4.88},
+			{Seller: sellers[1], Rank: 2, Score: 0.74, BayesianRating: 4.77},
+		},
+		Segments: []models.SegmentStats{{Segment: 80, Count: 1, MinPrice: 179990, MaxPrice: 179990, AvgPrice: 179990, MedianPrice: 179990}},
+		BuyBox: &models.BuyBoxPrediction{
+			TrainingSnapshots: 24,
+			Factors:           map[string]float64{"price": 0.6, "rating": 0.25, "delivery": 0.15},
+			Sellers:           []models.BuyBoxEstimate{{SellerID: "30358551", Name: "Mechta", Price: 179990, Position: 1, Probability: 0.64, TopSlotPrice: 179990}},
+		},
+		QuarantinedOffers: []models.QuarantinedOffer{testQuarantinedOffer(productID)},
+		TotalOffers:       4,
+		AnalysisTime:      testTime.Format(time.RFC3339),
+	}
+}
+
+func testQuarantinedOffer(productID string) models.QuarantinedOffer {
+	return models.QuarantinedOffer{
+		ID: 1, ProductID: productID, CityID: models.DefaultCityID, MerchantID: "99999", Price: 1,
+		Reasons: []string{"price is 1000x below median"}, CreatedAt: testTime,
+	}
+}
+
+func testWatchlistEntry(productID string) models.WatchlistEntry {
+	return models.WatchlistEntry{
+		ProductID: productID, Tags: []string{"tv"}, Cities: []string{models.DefaultCityID}, Priority: 10,
+		PollIntervalSeconds: 300, OwnSKU: "55421", Notes: "флагман категории", CreatedAt: testTime, UpdatedAt: testTime,
+	}
+}
+
+func testAPIKeyRecord(workspaceID string) models.APIKey {
+	used := testTime.Add(time.Hour)
+	return models.APIKey{
+		ID: 1, WorkspaceID: workspaceID, Name: "dashboard", Prefix: "mq_0123",
+		Roles: []models.Role{models.RoleAdmin}, CreatedAt: testTime, LastUsedAt: &used,
+	}
+}
+
+// recordedRequest - первый документ json.txt: реальный ответ Kaspi в формате save-kaspi-data
+func recordedRequest(t *testing.T) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile("../../json.txt")
+	if err != nil {
+		t.Fatalf("read json.txt: %v", err)
+	}
+	document := regexp.MustCompile(`(?m)^-{3,}\s*$`).Split(string(data), 2)[0]
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(document)); err != nil {
+		t.Fatalf("json.txt: %v", err)
+	}
+	return compact.Bytes()
+}
+
+// contractResult - ошибки проверки по спецификации для одного запроса
+type contractResult struct {
+	route        string
+	requestErrs  []openapi.FieldError
+	responseErrs []openapi.FieldError
+}
+
+// newContractRouter собирает маршруты как serve, а после Authorize проверяет запрос и ответ по openapi.json
+func newContractRouter(t *testing.T, results *[]contractResult) *mux.Router {
+	t.Helper()
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator := openapi.NewValidator(doc, true, true)
+	handler := NewHTTPHandler(fakeService{}, Config{})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.Use(handler.Authorize)
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			template, _ := route.GetPathTemplate()
+			operation := doc.Operation(template, r.Method)
+			if operation == nil {
+				t.Errorf("%s %s is not in openapi.json", r.Method, template)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result := contractResult{route: r.Method + " " + template}
+			result.requestErrs = validator.ValidateRequest(r, operation)
+			recorder := httptest.NewRecorder()
+			next.ServeHTTP(recorder, r)
+			result.responseErrs = validator.ValidateResponse(operation, recorder.Code, recorder.Header(), recorder.Body.Bytes())
+			*results = append(*results, result)
+
+			for name, values := range recorder.Header() {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(recorder.Code)
+			w.Write(recorder.Body.Bytes())
+		})
+	})
+	return router
+}
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	kaspiData := recordedRequest(t)
+	csv := "product_id,merchantId,merchantName,price,position\n121806358,30358551,Mechta,\"179 990 ₸\",1\n121806358,15503068,Sulpak,184990,2\n"
+
+	tests := []struct {
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{method: "GET", path: "/health", wantStatus: http.StatusOK},
+		{method: "GET", path: "/livez", wantStatus: http.StatusOK},
+		{method: "GET", path: "/readyz", wantStatus: http.StatusOK},
+		{method: "GET", path: "/openapi.json", wantStatus: http.StatusOK},
+		{method: "GET", path: "/docs", wantStatus: http.StatusOK},
+		{method: "POST", path: "/products/analyze", body: `{"product_ids":["121806358","` + missingProductID + `"],"merchant_id":"30358551"}`, wantStatus: http.StatusOK},
+		{method: "POST", path: "/products/analyze?format=csv", body: `{"product_ids":["121806358"]}`, wantStatus: http.StatusOK},
+		{method: "POST", path: "/products/analyze", body: `{}`, wantStatus: http.StatusBadRequest},
+		{method: "GET", path: "/products/121806358/analyze?city=750000000", wantStatus: http.StatusOK},
+		{method: "GET", path: "/products/" + missingProductID + "/analyze", wantStatus: http.StatusNotFound},
+		{method: "GET", path: "/products/121806358/history?city=750000000", wantStatus: http.StatusOK},
+		{method: "GET", path: "/products/121806358/info", wantStatus: http.StatusOK},
+		{method: "GET", path: "/products/121806358/backtest?strategies=min,optimal&unit_cost=150000&from=2024-05-01", wantStatus: http.StatusOK},
+		{method: "GET", path: "/products/121806358/backtest?from=2024-13-45", wantStatus: http.StatusBadRequest},
+		{method: "GET", path: "/products/121806358/cities", wantStatus: http.StatusOK},
+		{method: "GET", path: "/quarantine?product_id=121806358", wantStatus: http.StatusOK},
+		{method: "POST", path: "/products/save-kaspi-data", body: string(kaspiData), wantStatus: http.StatusAccepted},
+		{method: "POST", path: "/products/save-kaspi-data?sync=true", body: string(kaspiData), wantStatus: http.StatusCreated},
+		{method: "POST", path: "/products/save-kaspi-data", body: `{"product_id":"121806358","offers":{"offers":[]}}`, wantStatus: http.StatusBadRequest},
+		{method: "POST", path: "/products/ingest", contentType: "application/x-ndjson", body: string(kaspiData) + "\n{\n", wantStatus: http.StatusOK},
+		{method: "POST", path: "/products/import", contentType: "text/csv", body: csv, wantStatus: http.StatusOK},
+		{method: "POST", path: "/products/import", body: csv, wantStatus: http.StatusBadRequest},
+		{method: "GET", path: "/jobs/1", wantStatus: http.StatusOK},
+		{method: "GET", path: "/watchlist?tag=tv", wantStatus: http.StatusOK},
+		{method: "GET", path: "/watchlist/121806358", wantStatus: http.StatusOK},
+		{method: "POST", path: "/watchlist", body: `{"product_id":"121806358","tags":["tv"],"cities":["750000000"],"priority":10,"poll_interval_seconds":300}`, wantStatus: http.StatusCreated},
+		{method: "DELETE", path: "/watchlist/121806358", wantStatus: http.StatusNoContent},
+		{method: "GET", path: "/workspace", wantStatus: http.StatusOK},
+		{method: "PUT", path: "/workspace", body: `{"name":"Electronics","own_merchant_id":"30358551"}`, wantStatus: http.StatusOK},
+		{method: "POST", path: "/api-keys", body: `{"name":"scraper","roles":["ingest"]}`, wantStatus: http.StatusCreated},
+		{method: "GET", path: "/api-keys", wantStatus: http.StatusOK},
+		{method: "DELETE", path: "/api-keys/1", wantStatus: http.StatusNoContent},
+	}
+
+	var results []contractResult
+	router := newContractRouter(t, &results)
+
+	for _, tt := range tests {
+		results = results[:0]
+		r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		r.Header.Set("X-API-Key", testAPIKey)
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		name := tt.method + " " + tt.path
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", name, w.Code, tt.wantStatus, w.Body)
+		}
+		if len(results) != 1 {
+			t.Errorf("%s: validated %d times, want once", name, len(results))
+			continue
+		}
+		if errs := results[0].requestErrs; len(errs) > 0 {
+			t.Errorf("%s: request does not match openapi.json: %+v", name, errs)
+		}
+		if errs := results[0].responseErrs; len(errs) > 0 {
+			t.Errorf("%s: %d response does not match openapi.json: %+v", name, w.Code, errs)
+		}
+	}
+
+	// Каждый маршрут из RegisterRoutes проверен хотя бы одним запросом
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			template, _ := match.Route.GetPathTemplate()
+			covered[tt.method+" "+template] = true
+		}
+	}
+	var missing []string
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			if !covered[method+" "+template] {
+				missing = append(missing, method+" "+template)
+			}
+		}
+		return nil
+	})
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("routes without a contract test case: %s", strings.Join(missing, ", "))
+	}
+}
+
+func TestValidatorRejectsRequestsBeforeHandler(t *testing.T) {
+	var results []contractResult
+	router := newContractRouter(t, &results)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   []openapi.FieldError
+	}{
+		{
+			method: "GET",
+			path:   "/products/121806358/analyze?format=pdf",
+			want:   []openapi.FieldError{{Field: "query.format", Message: "must be one of json, csv, xlsx"}},
+		},
+		{
+			method: "POST",
+			path:   "/watchlist",
+			body:   `{"product_id":"121806358","priority":"high"}`,
+			want:   []openapi.FieldError{{Field: "body.priority", Message: "must be a integer"}},
+		},
+	}
+	for _, tt := range tests {
+		results = results[:0]
+		r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		r.Header.Set("X-API-Key", testAPIKey)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+
+		if len(results) != 1 {
+			t.Fatalf("%s %s: validated %d times", tt.method, tt.path, len(results))
+		}
+		got := results[0].requestErrs
+		if len(got) != len(tt.want) {
+			t.Errorf("%s %s: request errors %+v, want %+v", tt.method, tt.path, got, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s %s: request error %+v, want %+v", tt.method, tt.path, got[i], tt.want[i])
+			}
+		}
+	}
+}
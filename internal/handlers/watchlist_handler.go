@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/core/models"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Минимальный интервал опроса товара, чтобы не получить бан от Kaspi
+const minPollIntervalSeconds = 60
+
+func (h *HTTPHandler) SaveWatchlistEntry(w http.ResponseWriter, r *http.Request) {
+	var entry models.WatchlistEntry
+
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if entry.ProductID == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Product ID is required")
+		return
+	}
+
+	if entry.PollIntervalSeconds != 0 && entry.PollIntervalSeconds < minPollIntervalSeconds {
+		respondWithError(w, r, http.StatusBadRequest, "poll_interval_seconds must be 0 (default) or at least 60")
+		return
+	}
+
+	if err := h.service.SaveWatchlistEntry(r.Context(), workspaceID(r), &entry); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, entry)
+}
+
+func (h *HTTPHandler) GetWatchlist(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.service.GetWatchlist(r.Context(), workspaceID(r), tagsParam(r))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, entries)
+}
+
+func (h *HTTPHandler) GetWatchlistEntry(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.service.GetWatchlistEntry(r.Context(), workspaceID(r), mux.Vars(r)["productId"])
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, entry)
+}
+
+func (h *HTTPHandler) DeleteWatchlistEntry(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteWatchlistEntry(r.Context(), workspaceID(r), mux.Vars(r)["productId"]); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tagsParam собирает теги из ?tag=a&tag=b и ?tags=a,b
+func tagsParam(r *http.Request) []string {
+	query := r.URL.Query()
+	tags := query["tag"]
+	for _, value := range query["tags"] {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	return tags
+}
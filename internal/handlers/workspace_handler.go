@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"Mini-Quicko/internal/auth"
+	"Mini-Quicko/internal/core/models"
+	"encoding/json"
+	"net/http"
+)
+
+// workspaceID - рабочее пространство API ключа запроса. Без проверки ключей (auth.enabled: false)
+// все запросы работают с рабочим пространством по умолчанию.
+func workspaceID(r *http.Request) string {
+	if key := auth.FromContext(r.Context()); key != nil {
+		return key.WorkspaceID
+	}
+	return models.DefaultWorkspaceID
+}
+
+func (h *HTTPHandler) GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	workspace, err := h.service.GetWorkspace(r.Context(), workspaceID(r))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, workspace)
+}
+
+func (h *HTTPHandler) UpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var request models.UpdateWorkspaceRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	workspace := &models.Workspace{
+		ID:            workspaceID(r),
+		Name:          request.Name,
+		OwnMerchantID: request.OwnMerchantID,
+	}
+	if err := h.service.UpdateWorkspace(r.Context(), workspace); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, workspace)
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+)
+
+// Database проверяет соединение с БД
+func Database(ping func(ctx context.Context) error) Check {
+	return Check{Name: "database", Critical: true, Run: func(ctx context.Context, _ Thresholds) Result {
+		if err := ping(ctx); err != nil {
+			return failed(ctx, "database", err)
+		}
+		return Result{}
+	}}
+}
+
+// Migrations проверяет, что схема БД соответствует коду: без примененных миграций запросы падают
+func Migrations(repo ports.Repository) Check {
+	return Check{Name: "migrations", Critical: true, Run: func(ctx context.Context, _ Thresholds) Result {
+		pending, err := repo.PendingMigrations(ctx)
+		if err != nil {
+			return failed(ctx, "migrations", err)
+		}
+		if len(pending) > 0 {
+			return Result{
+				Status:  models.HealthUnhealthy,
+				Message: fmt.Sprintf("%d migrations are not applied, run `server migrate`", len(pending)),
+				Details: map[string]any{"pending": pending},
+			}
+		}
+		return Result{}
+	}}
+}
+
+// Queue проверяет глубину очереди загрузки и задержку самой давней задачи
+func Queue(repo ports.Repository) Check {
+	return Check{Name: "queue", Run: func(ctx context.Context, thresholds Thresholds) Result {
+		now := time.Now()
+		stats, err := repo.QueueStats(ctx, now)
+		if err != nil {
+			return failed(ctx, "queue", err)
+		}
+
+		result := Result{Details: map[string]any{"queued": stats.Queued, "running": stats.Running}}
+		if stats.OldestQueuedAt != nil {
+			result.Details["oldest_wait_seconds"] = int(now.Sub(*stats.OldestQueuedAt).Seconds())
+		}
+		if maxDepth := thresholds.MaxQueueDepth; maxDepth > 0 && stats.Queued > maxDepth {
+			result.Status = models.HealthDegraded
+			result.Message = fmt.Sprintf("%d queued jobs exceed the limit of %d", stats.Queued, maxDepth)
+		}
+		return result
+	}}
+}
+
+// Scheduler проверяет отставание опроса Kaspi от расписания
+func Scheduler(lag func() time.Duration) Check {
+	return Check{Name: "scheduler", Run: func(ctx context.Context, thresholds Thresholds) Result {
+		current := lag()
+		result := Result{Details: map[string]any{"lag_seconds": int(current.Seconds())}}
+		if maxLag := thresholds.MaxSchedulerLag; maxLag > 0 && current > maxLag {
+			result.Status = models.HealthDegraded
+			result.Message = fmt.Sprintf("fetching is %s behind schedule", current.Round(time.Second))
+		}
+		return result
+	}}
+}
+
+// Ingestion проверяет, как давно сохранялись данные: при остановке парсера или планировщика данные устаревают
+func Ingestion(repo ports.Repository) Check {
+	return Check{Name: "ingestion", Run: func(ctx context.Context, thresholds Thresholds) Result {
+		last, err := repo.LastIngestionTime(ctx)
+		if err != nil {
+			return failed(ctx, "ingestion", err)
+		}
+		if last == nil {
+			return Result{Status: models.HealthDegraded, Message: "no data has been ingested yet"}
+		}
+
+		age := time.Since(*last)
+		result := Result{Details: map[string]any{"last_ingestion": last.UTC(), "age_seconds": int(age.Seconds())}}
+		if maxAge := thresholds.MaxIngestionAge; maxAge > 0 && age > maxAge {
+			result.Status = models.HealthDegraded
+			result.Message = fmt.Sprintf("last data was ingested %s ago", age.Round(time.Minute))
+		}
+		return result
+	}}
+}
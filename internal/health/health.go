@@ -0,0 +1,116 @@
+// Package health проверяет компоненты сервиса для /health: БД, миграции, очередь загрузки,
+// планировщик и свежесть данных. Проверки выполняются параллельно, у каждой свой таймаут
+// и замер длительности.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/logging"
+)
+
+// Result - результат проверки. Пустой Status означает healthy.
+type Result struct {
+	Status  models.HealthStatus
+	Message string
+	Details map[string]any
+}
+
+// Thresholds - пороги, при превышении которых компонент получает статус degraded. Нулевой порог не проверяется.
+type Thresholds struct {
+	MaxQueueDepth   int
+	MaxSchedulerLag time.Duration
+	MaxIngestionAge time.Duration
+}
+
+// Check - проверка компонента
+type Check struct {
+	Name     string
+	Critical bool // отказ делает сервис unhealthy, иначе только degraded
+	Run      func(ctx context.Context, thresholds Thresholds) Result
+}
+
+// Checker выполняет набор проверок
+type Checker struct {
+	timeout    time.Duration
+	checks     []Check
+	thresholds atomic.Pointer[Thresholds]
+}
+
+func NewChecker(timeout time.Duration, thresholds Thresholds, checks ...Check) *Checker {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	c := &Checker{timeout: timeout, checks: checks}
+	c.SetThresholds(thresholds)
+	return c
+}
+
+// SetThresholds меняет пороги без перезапуска
+func (c *Checker) SetThresholds(thresholds Thresholds) {
+	c.thresholds.Store(&thresholds)
+}
+
+// Run выполняет проверки и сводит их в отчет в порядке регистрации
+func (c *Checker) Run(ctx context.Context) *models.HealthReport {
+	report := &models.HealthReport{
+		Status:     models.HealthHealthy,
+		Components: make([]models.ComponentHealth, len(c.checks)),
+		CheckedAt:  time.Now().UTC(),
+	}
+
+	thresholds := *c.thresholds.Load()
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Components[i] = c.run(ctx, check, thresholds)
+		}()
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		switch {
+		case component.Status == models.HealthHealthy:
+		case component.Critical && component.Status == models.HealthUnhealthy:
+			report.Status = models.HealthUnhealthy
+		case report.Status == models.HealthHealthy:
+			report.Status = models.HealthDegraded
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check, thresholds Thresholds) models.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := check.Run(ctx, thresholds)
+	if result.Status == "" {
+		result.Status = models.HealthHealthy
+	}
+
+	return models.ComponentHealth{
+		Name:      check.Name,
+		Status:    result.Status,
+		Critical:  check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Message:   result.Message,
+		Details:   result.Details,
+	}
+}
+
+// failed - результат неудавшейся проверки. Текст ошибки пишется в лог, а в ответ
+// попадает только безопасное сообщение: /health доступен без API ключа.
+func failed(ctx context.Context, name string, err error) Result {
+	slog.WarnContext(ctx, "health check failed", "component", name, logging.Err(err))
+	return Result{Status: models.HealthUnhealthy, Message: errs.Message(err)}
+}
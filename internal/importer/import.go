@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"context"
+	"log/slog"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/logging"
+)
+
+// ImportTable разбирает строки таблицы и загружает корректные офферы через SaveKaspiData,
+// по одному запросу на товар и город в рабочее пространство workspaceID. Ошибки строк и товаров собираются в сводку.
+func ImportTable(ctx context.Context, service ports.Service, workspaceID string, rows [][]string, mapping Mapping, cityID string) (*models.ImportSummary, error) {
+	requests, rowErrors, err := ParseOffers(rows, mapping, cityID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ImportSummary{
+		Invalid:  countRows(rowErrors),
+		Errors:   rowErrors,
+		Products: make([]models.IngestResult, 0, len(requests)),
+	}
+	if summary.Errors == nil {
+		summary.Errors = []models.ImportRowError{}
+	}
+	for _, record := range rows[1:] {
+		if !isBlank(record) {
+			summary.Rows++
+		}
+	}
+
+	for i, request := range requests {
+		result := models.IngestResult{
+			Line:      i + 1,
+			ProductID: request.ProductID,
+			Status:    "error",
+			Offers:    len(request.Offers.Offers),
+		}
+
+		analysis, err := service.SaveKaspiData(ctx, workspaceID, request)
+		if err != nil {
+			result.Error = errs.Message(err)
+			if kind := errs.KindOf(err); kind == errs.KindInternal || kind == errs.KindUnavailable {
+				slog.WarnContext(ctx, "failed to import product", "line", i+1, "product_id", request.ProductID, logging.Err(err))
+			}
+			summary.Failed++
+		} else {
+			result.Status = "ok"
+			result.Quarantined = len(analysis.QuarantinedOffers)
+			result.MinPrice = analysis.MinPrice
+			result.OptimalPrice = analysis.OptimalPrice
+			summary.Succeeded++
+		}
+		summary.Products = append(summary.Products, result)
+
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		}
+	}
+
+	return summary, nil
+}
+
+// countRows считает строки с ошибками (у строки может быть несколько ошибок)
+func countRows(rowErrors []models.ImportRowError) int {
+	rows := make(map[int]bool)
+	for _, e := range rowErrors {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
@@ -0,0 +1,60 @@
+// Package importer читает выгрузки офферов Kaspi из файлов для загрузки через SaveKaspiData.
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// separator - строка из дефисов между документами в файлах формата json.txt
+var separator = regexp.MustCompile(`^-{3,}\s*$`)
+
+// ReadKaspiRequests последовательно читает запросы save-kaspi-data из r и передает их в fn.
+// Поддерживаются NDJSON, подряд идущие JSON-документы и формат json.txt с разделителями из дефисов.
+// Файл читается потоково, ошибка fn прерывает чтение.
+func ReadKaspiRequests(r io.Reader, fn func(index int, request *models.KaspiDataRequest) error) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(stripSeparators(r, pw))
+	}()
+	defer pr.Close()
+
+	decoder := json.NewDecoder(pr)
+	for index := 1; ; index++ {
+		var request models.KaspiDataRequest
+		if err := decoder.Decode(&request); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("document %d: %w", index, err)
+		}
+		if err := fn(index, &request); err != nil {
+			return err
+		}
+	}
+}
+
+// stripSeparators копирует r в w построчно, пропуская строки-разделители
+func stripSeparators(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && !separator.Match(line) {
+			if _, werr := w.Write(line); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// Mapping задает колонку файла для поля оффера: поле -> заголовок колонки.
+// Поля называются как в JSON save-kaspi-data (price, merchantId, ...), плюс product_id, city_id и position.
+// Поля без явного сопоставления ищутся по стандартным заголовкам, в том числе из нашей выгрузки.
+type Mapping map[string]string
+
+// NewMapping проверяет имена полей; регистр не важен, так как viper приводит ключи к нижнему регистру
+func NewMapping(columns map[string]string) (Mapping, error) {
+	mapping := make(Mapping, len(columns))
+	for name, column := range columns {
+		field, ok := canonicalField(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q in column mapping", name)
+		}
+		if column = strings.TrimSpace(column); column == "" {
+			return nil, fmt.Errorf("empty column for field %q in column mapping", name)
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// ParseMapping разбирает сопоставление вида "price=Цена,merchantId=ID магазина"
+func ParseMapping(value string) (Mapping, error) {
+	columns := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(field) == "" {
+			return nil, fmt.Errorf("invalid column mapping %q: expected field=column", pair)
+		}
+		columns[strings.TrimSpace(field)] = column
+	}
+	return NewMapping(columns)
+}
+
+func canonicalField(name string) (string, bool) {
+	for field := range fieldAliases {
+		if strings.EqualFold(field, strings.TrimSpace(name)) {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// Merge возвращает сопоставление, где поля из override заменяют поля m
+func (m Mapping) Merge(override Mapping) Mapping {
+	merged := make(Mapping, len(m)+len(override))
+	for field, column := range m {
+		merged[field] = column
+	}
+	for field, column := range override {
+		merged[field] = column
+	}
+	return merged
+}
+
+// fieldAliases - поля оффера и стандартные заголовки колонок для них
+var fieldAliases = map[string][]string{
+	"product_id":              {"product_id", "productId", "masterSku", "Товар", "Product ID"},
+	"city_id":                 {"city_id", "cityId", "Город", "City ID"},
+	"position":                {"position", "Позиция в выдаче", "Listing position"},
+	"merchantId":              {"merchantId", "merchant_id", "seller_id", "ID продавца", "Seller ID"},
+	"merchantName":            {"merchantName", "merchant_name", "seller_name", "Продавец", "Seller"},
+	"merchantSku":             {"merchantSku", "merchant_sku", "sku"},
+	"merchantRating":          {"merchantRating", "rating", "Рейтинг", "Rating"},
+	"merchantReviewsQuantity": {"merchantReviewsQuantity", "reviews", "Отзывы", "Reviews"},
+	"merchantSegmentId":       {"merchantSegmentId", "segment", "Сегмент", "Segment"},
+	"purchaseCount":           {"purchaseCount", "purchases", "Покупки", "Purchases"},
+	"title":                   {"title", "Название", "Title"},
+	"price":                   {"price", "Цена", "Price"},
+	"priceBeforeDiscount":     {"priceBeforeDiscount", "price_before_discount", "Цена без скидки"},
+	"discount":                {"discount", "Скидка", "Discount"},
+	"deliveryType":            {"deliveryType", "delivery_type"},
+	"deliveryDuration":        {"deliveryDuration", "delivery_duration"},
+	"kaspiDelivery":           {"kaspiDelivery", "kaspi_delivery"},
+}
+
+var requiredFields = []string{"product_id", "merchantId", "price"}
+
+// ParseOffers превращает строки таблицы (первая - заголовки) в запросы save-kaspi-data,
+// по одному на товар и город. Строки с ошибками пропускаются и попадают в список ошибок.
+// Офферы товара идут в порядке position, а без него - в порядке строк файла.
+func ParseOffers(rows [][]string, mapping Mapping, defaultCityID string) ([]*models.KaspiDataRequest, []models.ImportRowError, error) {
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+
+	columns, err := resolveColumns(rows[0], mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type group struct {
+		request   *models.KaspiDataRequest
+		positions []int
+	}
+	var groups []*group
+	byKey := make(map[string]*group)
+	var rowErrors []models.ImportRowError
+
+	for i, record := range rows[1:] {
+		rowNumber := i + 2 // номер строки в файле с учетом заголовка
+		if isBlank(record) {
+			continue
+		}
+
+		offer, productID, cityID, position, errs := parseRow(rowNumber, record, rows[0], columns)
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+		if cityID == "" {
+			cityID = defaultCityID
+		}
+
+		key := productID + "@" + cityID
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{request: &models.KaspiDataRequest{ProductID: productID, CityID: cityID}}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.request.Offers.Offers = append(g.request.Offers.Offers, offer)
+		g.positions = append(g.positions, position)
+	}
+
+	requests := make([]*models.KaspiDataRequest, 0, len(groups))
+	for _, g := range groups {
+		offers := g.request.Offers.Offers
+		sort.Stable(byPosition{offers, g.positions})
+		g.request.Offers.Total = len(offers)
+		g.request.Offers.OffersCount = len(offers)
+		requests = append(requests, g.request)
+	}
+	return requests, rowErrors, nil
+}
+
+// resolveColumns находит номер колонки для каждого поля по заголовкам
+func resolveColumns(header []string, mapping Mapping) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, title := range header {
+		key := normalizeHeader(title)
+		if _, exists := index[key]; !exists {
+			index[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, aliases := range fieldAliases {
+		if column, ok := mapping[field]; ok {
+			i, found := index[normalizeHeader(column)]
+			if !found {
+				return nil, fmt.Errorf("column %q mapped to %s not found in header", column, field)
+			}
+			columns[field] = i
+			continue
+		}
+		for _, alias := range aliases {
+			if i, found := index[normalizeHeader(alias)]; found {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	var missing []string
+	for _, field := range requiredFields {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("required columns not found: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+func parseRow(rowNumber int, record, header []string, columns map[string]int) (models.Offer, string, string, int, []models.ImportRowError) {
+	var offer models.Offer
+	var errs []models.ImportRowError
+
+	value := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	// В ошибке указываем заголовок колонки из файла, а для отсутствующей колонки - имя поля
+	fail := func(field, raw, message string) {
+		column := field
+		if i, ok := columns[field]; ok {
+			column = header[i]
+		}
+		errs = append(errs, models.ImportRowError{Row: rowNumber, Column: column, Value: raw, Message: message})
+	}
+	number := func(field string, target *float64, min, max float64) {
+		raw := value(field)
+		if raw == "" {
+			return
+		}
+		v, err := parseNumber(raw)
+		switch {
+		case err != nil:
+			fail(field, raw, "not a number")
+		case v < min:
+			fail(field, raw, fmt.Sprintf("must be at least %g", min))
+		case v > max:
+			fail(field, raw, fmt.Sprintf("must be at most %g", max))
+		default:
+			*target = v
+		}
+	}
+	integer := func(field string, target *int) {
+		var v float64
+		number(field, &v, 0, 1e12)
+		*target = int(v)
+	}
+
+	productID := value("product_id")
+	if productID == "" {
+		fail("product_id", "", "product ID is required")
+	}
+	offer.MasterSku = productID
+	offer.MerchantId = value("merchantId")
+	if offer.MerchantId == "" {
+		fail("merchantId", "", "merchant ID is required")
+	}
+	offer.MerchantName = value("merchantName")
+	offer.MerchantSku = value("merchantSku")
+	offer.Title = value("title")
+	offer.DeliveryType = value("deliveryType")
+	offer.DeliveryDuration = value("deliveryDuration")
+
+	if raw := value("price"); raw == "" {
+		fail("price", "", "price is required")
+	} else {
+		before := len(errs)
+		number("price", &offer.Price, 0, 1e10)
+		if len(errs) == before && offer.Price <= 0 {
+			fail("price", raw, "price must be positive")
+		}
+	}
+	number("priceBeforeDiscount", &offer.PriceBeforeDiscount, 0, 1e10)
+	number("merchantRating", &offer.MerchantRating, 0, 5)
+	number("merchantSegmentId", &offer.MerchantSegmentId, 0, 100)
+	integer("merchantReviewsQuantity", &offer.MerchantReviewsQuantity)
+	integer("purchaseCount", &offer.PurchaseCount)
+	integer("discount", &offer.Discount)
+
+	var position int
+	integer("position", &position)
+
+	if raw := value("kaspiDelivery"); raw != "" {
+		if v, ok := parseBool(raw); ok {
+			offer.KaspiDelivery = v
+		} else {
+			fail("kaspiDelivery", raw, "expected true or false")
+		}
+	}
+
+	return offer, productID, value("city_id"), position, errs
+}
+
+// parseNumber разбирает число в записи из таблиц: "179 990 ₸", "179990,50", "1,299.00", "1,299".
+// Запятая без точки - десятичный разделитель, если только она не делит число на группы по три цифры
+// ("1,299", "1,299,000"): так цену выгружает Excel в английской локали.
+func parseNumber(raw string) (float64, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '₸', '%':
+			return -1
+		}
+		return r
+	}, raw)
+	cleaned = strings.TrimSuffix(strings.TrimSuffix(cleaned, "тг."), "тг")
+
+	if strings.Contains(cleaned, ",") {
+		if strings.Contains(cleaned, ".") {
+			cleaned = strings.ReplaceAll(cleaned, ",", "")
+		} else if thousandsGrouped(cleaned) {
+			cleaned = strings.ReplaceAll(cleaned, ",", "")
+		} else {
+			cleaned = strings.Replace(cleaned, ",", ".", 1)
+		}
+	}
+	return strconv.ParseFloat(cleaned, 64)
+}
+
+// thousandsGrouped сообщает, что запятые делят число на тысячи: первая группа из 1-3 цифр
+// без ведущего нуля, остальные - ровно по три цифры
+func thousandsGrouped(value string) bool {
+	groups := strings.Split(strings.TrimPrefix(value, "-"), ",")
+	first := groups[0]
+	if len(first) == 0 || len(first) > 3 || first[0] == '0' || !isDigits(first) {
+		return false
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 || !isDigits(group) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseBool(raw string) (bool, bool) {
+	switch strings.ToLower(raw) {
+	case "true", "1", "yes", "y", "да":
+		return true, true
+	case "false", "0", "no", "n", "нет":
+		return false, true
+	}
+	return false, false
+}
+
+func normalizeHeader(title string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(title, "\ufeff")))
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// byPosition сортирует офферы по позиции в выдаче, офферы без позиции - в конце в порядке файла
+type byPosition struct {
+	offers    []models.Offer
+	positions []int
+}
+
+func (b byPosition) Len() int { return len(b.offers) }
+
+func (b byPosition) Less(i, j int) bool {
+	pi, pj := b.positions[i], b.positions[j]
+	if pi <= 0 || pj <= 0 {
+		return pi > 0 && pj <= 0
+	}
+	return pi < pj
+}
+
+func (b byPosition) Swap(i, j int) {
+	b.offers[i], b.offers[j] = b.offers[j], b.offers[i]
+	b.positions[i], b.positions[j] = b.positions[j], b.positions[i]
+}
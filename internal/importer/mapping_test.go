@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    float64
+		wantErr bool
+	}{
+		{raw: "179990", want: 179990},
+		{raw: "179 990 ₸", want: 179990},
+		{raw: "179 990 тг", want: 179990},
+		{raw: "179990тг.", want: 179990},
+		{raw: "179990,50", want: 179990.5},
+		{raw: "179990,500", want: 179990.5},
+		{raw: "0,500", want: 0.5},
+		{raw: "4,5", want: 4.5},
+		{raw: "1,299", want: 1299},
+		{raw: "1,299,000", want: 1299000},
+		{raw: "-1,299", want: -1299},
+		{raw: "1,299.00", want: 1299},
+		{raw: "1,299,000.50", want: 1299000.5},
+		{raw: "12.5%", want: 12.5},
+		{raw: "1,29,9", wantErr: true},
+		{raw: "1,2,3", wantErr: true},
+		{raw: "abc", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseNumber(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseNumber(%q) = %v, want error", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseNumber(%q) = %v, %v, want %v", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseOffersRowErrors(t *testing.T) {
+	header := []string{"product_id", "Продавец", "ID продавца", "Цена", "Рейтинг", "Покупки", "kaspi_delivery"}
+
+	tests := []struct {
+		name string
+		row  []string
+		want []models.ImportRowError
+	}{
+		{
+			name: "valid row",
+			row:  []string{"101", "Shop", "m1", "1,299", "4,8", "12", "да"},
+		},
+		{
+			name: "missing required fields",
+			row:  []string{"", "Shop", "", "", "", "", ""},
+			want: []models.ImportRowError{
+				{Row: 2, Column: "product_id", Message: "product ID is required"},
+				{Row: 2, Column: "ID продавца", Message: "merchant ID is required"},
+				{Row: 2, Column: "Цена", Message: "price is required"},
+			},
+		},
+		{
+			name: "not a number",
+			row:  []string{"101", "Shop", "m1", "дорого", "", "", ""},
+			want: []models.ImportRowError{{Row: 2, Column: "Цена", Value: "дорого", Message: "not a number"}},
+		},
+		{
+			name: "price not positive",
+			row:  []string{"101", "Shop", "m1", "0", "", "", ""},
+			want: []models.ImportRowError{{Row: 2, Column: "Цена", Value: "0", Message: "price must be positive"}},
+		},
+		{
+			name: "out of range",
+			row:  []string{"101", "Shop", "m1", "100", "5,5", "-1", ""},
+			want: []models.ImportRowError{
+				{Row: 2, Column: "Рейтинг", Value: "5,5", Message: "must be at most 5"},
+				{Row: 2, Column: "Покупки", Value: "-1", Message: "must be at least 0"},
+			},
+		},
+		{
+			name: "invalid bool",
+			row:  []string{"101", "Shop", "m1", "100", "", "", "maybe"},
+			want: []models.ImportRowError{{Row: 2, Column: "kaspi_delivery", Value: "maybe", Message: "expected true or false"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, rowErrors, err := ParseOffers([][]string{header, tt.row}, nil, "750000000")
+			if err != nil {
+				t.Fatalf("ParseOffers: %v", err)
+			}
+			if !reflect.DeepEqual(rowErrors, tt.want) {
+				t.Errorf("row errors:\ngot  %+v\nwant %+v", rowErrors, tt.want)
+			}
+			if wantRequests := 1 - min(len(tt.want), 1); len(requests) != wantRequests {
+				t.Errorf("%d requests, want %d", len(requests), wantRequests)
+			}
+		})
+	}
+}
+
+func TestParseOffersGroupsRows(t *testing.T) {
+	rows := [][]string{
+		{"product_id", "city_id", "merchantId", "price", "position"},
+		{"101", "", "m2", "200", "2"},
+		{"", "", "", "", ""},
+		{"101", "", "m1", "100", "1"},
+		{"101", "710000000", "m3", "300", ""},
+		{"102", "", "m1", "150", ""},
+		{"102", "", "", "150", ""},
+	}
+
+	requests, rowErrors, err := ParseOffers(rows, nil, "750000000")
+	if err != nil {
+		t.Fatalf("ParseOffers: %v", err)
+	}
+	want := []models.ImportRowError{{Row: 7, Column: "merchantId", Message: "merchant ID is required"}}
+	if !reflect.DeepEqual(rowErrors, want) {
+		t.Errorf("row errors %+v, want %+v", rowErrors, want)
+	}
+
+	type group struct {
+		productID, cityID string
+		merchants         []string
+	}
+	var got []group
+	for _, request := range requests {
+		g := group{productID: request.ProductID, cityID: request.CityID}
+		for _, offer := range request.Offers.Offers {
+			g.merchants = append(g.merchants, offer.MerchantId)
+		}
+		if request.Offers.OffersCount != len(request.Offers.Offers) {
+			t.Errorf("%s@%s: offersCount %d for %d offers", request.ProductID, request.CityID, request.Offers.OffersCount, len(request.Offers.Offers))
+		}
+		got = append(got, g)
+	}
+	wantGroups := []group{
+		{productID: "101", cityID: "750000000", merchants: []string{"m1", "m2"}},
+		{productID: "101", cityID: "710000000", merchants: []string{"m3"}},
+		{productID: "102", cityID: "750000000", merchants: []string{"m1"}},
+	}
+	if !reflect.DeepEqual(got, wantGroups) {
+		t.Errorf("requests %+v, want %+v", got, wantGroups)
+	}
+}
+
+func TestParseOffersMissingColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		mapping Mapping
+	}{
+		{name: "required column", header: []string{"product_id", "merchantId"}},
+		{name: "mapped column", header: []string{"product_id", "merchantId", "price"}, mapping: Mapping{"price": "Цена тг"}},
+	}
+	for _, tt := range tests {
+		if _, _, err := ParseOffers([][]string{tt.header}, tt.mapping, ""); err == nil {
+			t.Errorf("%s: ParseOffers accepted header %v", tt.name, tt.header)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format - формат табличного файла
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// DetectFormat определяет табличный формат по имени файла или Content-Type
+func DetectFormat(name string) (Format, bool) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".csv"), strings.HasPrefix(name, "text/csv"), name == "csv":
+		return FormatCSV, true
+	case strings.HasSuffix(name, ".xlsx"), name == "xlsx",
+		strings.HasPrefix(name, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"):
+		return FormatXLSX, true
+	}
+	return "", false
+}
+
+// ReadTable читает первую таблицу файла: первая строка - заголовки
+func ReadTable(data []byte, format Format) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(data)
+	case FormatXLSX:
+		return readXLSX(data)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// readCSV читает CSV с разделителем ",", ";" или табуляцией (определяется по строке заголовков)
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	header, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine()
+	comma := ','
+	best := strings.Count(string(header), ",")
+	for _, candidate := range []rune{';', '\t'} {
+		if count := strings.Count(string(header), string(candidate)); count > best {
+			comma, best = candidate, count
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, record)
+	}
+}
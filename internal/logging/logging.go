@@ -0,0 +1,116 @@
+// Package logging настраивает структурированные логи log/slog: формат text или json и уровень
+// из конфигурации. Записи с контекстом (slog.InfoContext и т.п.) получают идентификатор
+// HTTP запроса, идентификаторы трассировки и атрибуты, добавленные в контекст функцией With.
+//
+// Setup заменяет логгер по умолчанию, поэтому сообщения пакета log тоже попадают в slog.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"Mini-Quicko/internal/requestid"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config - настройки логов
+type Config struct {
+	Format string // text или json
+	Level  string // debug, info, warn или error
+}
+
+// level - уровень логгера по умолчанию, меняется SetLevel без перезапуска
+var level = new(slog.LevelVar)
+
+// Setup создает логгер по настройкам и делает его логгером по умолчанию
+func Setup(cfg Config) (*slog.Logger, error) {
+	if err := SetLevel(cfg.Level); err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q: use text or json", cfg.Format)
+	}
+
+	logger := slog.New(contextHandler{handler})
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// SetLevel меняет уровень логгера, созданного Setup
+func SetLevel(value string) error {
+	parsed, err := ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// ParseLevel разбирает уровень логов; пустая строка - info
+func ParseLevel(value string) (slog.Level, error) {
+	var parsed slog.Level
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := parsed.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q: use debug, info, warn or error", value)
+	}
+	return parsed, nil
+}
+
+type attrsKey struct{}
+
+// With возвращает контекст, записи которого получат атрибуты args (пары ключ-значение,
+// как в slog.Logger.With). Так воркер добавляет job_id ко всем логам сервиса и репозитория
+// во время обработки задачи.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append([]slog.Attr(nil), contextAttrs(ctx)...)
+	attrs = append(attrs, slog.Group("", args...).Value.Group()...)
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func contextAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler дополняет записи данными из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	record.AddAttrs(contextAttrs(ctx)...)
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Err - атрибут ошибки, одинаковый во всех записях
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
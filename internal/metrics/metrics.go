@@ -0,0 +1,106 @@
+// Package metrics собирает метрики Prometheus: HTTP запросы, загрузку офферов, анализ,
+// запросы к БД и состояние пула соединений. Метрики отдаются обработчиком Handler на /metrics.
+//
+// Сервис и репозиторий инструментируются декораторами InstrumentService и InstrumentRepository,
+// поэтому реализации портов о метриках не знают.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "mini_quicko"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	ingestions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingestions_total",
+		Help:      "Ingested save-kaspi-data payloads by workspace and result (ok or error). Labelled by workspace rather than product to keep cardinality bounded; see product_ingestions_total.",
+	}, []string{"workspace_id", "status"})
+
+	productIngestions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "product_ingestions_total",
+		Help:      "Successfully ingested payloads by workspace and product. Only validated, saved ingests are counted and at most 1000 products get their own series; the rest are counted under product_id=\"other\".",
+	}, []string{"workspace_id", "product_id"})
+
+	lastIngestion = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_ingestion_timestamp_seconds",
+		Help:      "Unix time of the last successfully ingested payload.",
+	})
+
+	offersPerPayload = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "offers_per_payload",
+		Help:      "Number of offers in an ingested payload.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200, 500},
+	})
+
+	analysisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "analysis_duration_seconds",
+		Help:      "Duration of analysis operations: ingest, analyze, bulk_analyze, compare_cities, backtest.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	dumpingDetections = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dumping_detections_total",
+		Help:      "Dumping sellers found in ingested payloads.",
+	})
+
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_query_duration_seconds",
+		Help:      "Latency of repository methods.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"method"})
+
+	repositoryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "repository_errors_total",
+		Help:      "Failed repository method calls.",
+	}, []string{"method"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		ingestions, productIngestions, lastIngestion, offersPerPayload,
+		analysisDuration, dumpingDetections,
+		repositoryDuration, repositoryErrors,
+	)
+}
+
+// RegisterDB добавляет метрики пула соединений из sql.DB.Stats()
+func RegisterDB(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler отдает метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import "sync"
+
+// maxTrackedProducts ограничивает число рядов product_ingestions_total: product_id задает клиент,
+// и без предела каждый новый товар добавлял бы ряд навсегда
+const maxTrackedProducts = 1000
+
+// otherProducts - метка для товаров сверх предела
+const otherProducts = "other"
+
+// productLabels выдает метку product_id, пока различных товаров не больше предела
+type productLabels struct {
+	mu    sync.Mutex
+	limit int
+	seen  map[[2]string]struct{}
+}
+
+func newProductLabels(limit int) *productLabels {
+	return &productLabels{limit: limit, seen: make(map[[2]string]struct{})}
+}
+
+// label возвращает productID, если товар уже учтен или место еще есть, иначе otherProducts
+func (p *productLabels) label(workspaceID, productID string) string {
+	key := [2]string{workspaceID, productID}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.seen[key]; ok {
+		return productID
+	}
+	if len(p.seen) >= p.limit {
+		return otherProducts
+	}
+	p.seen[key] = struct{}{}
+	return productID
+}
+
+var trackedProducts = newProductLabels(maxTrackedProducts)
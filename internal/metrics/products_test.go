@@ -0,0 +1,24 @@
+package metrics
+
+import "testing"
+
+func TestProductLabelsAreBounded(t *testing.T) {
+	labels := newProductLabels(2)
+
+	steps := []struct {
+		workspaceID string
+		productID   string
+		want        string
+	}{
+		{workspaceID: "w1", productID: "p1", want: "p1"},
+		{workspaceID: "w2", productID: "p1", want: "p1"},
+		{workspaceID: "w1", productID: "p2", want: otherProducts},
+		{workspaceID: "w1", productID: "p1", want: "p1"},
+		{workspaceID: "w2", productID: "p1", want: "p1"},
+	}
+	for i, step := range steps {
+		if got := labels.label(step.workspaceID, step.productID); got != step.want {
+			t.Errorf("step %d: label(%q, %q) = %q, want %q", i+1, step.workspaceID, step.productID, got, step.want)
+		}
+	}
+}
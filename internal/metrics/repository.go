@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+)
+
+// repository измеряет длительность и ошибки каждого метода репозитория
+type repository struct {
+	next ports.Repository
+}
+
+// InstrumentRepository оборачивает репозиторий сбором метрик запросов к БД
+func InstrumentRepository(next ports.Repository) ports.Repository {
+	return &repository{next: next}
+}
+
+func observeQuery(method string, start time.Time, err *error) {
+	repositoryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		repositoryErrors.WithLabelValues(method).Inc()
+	}
+}
+
+func (r *repository) GetPriceHistory(ctx context.Context, workspaceID, productID, cityID string, limit int) (_ []models.PriceHistory, err error) {
+	defer observeQuery("GetPriceHistory", time.Now(), &err)
+	return r.next.GetPriceHistory(ctx, workspaceID, productID, cityID, limit)
+}
+
+func (r *repository) GetProductInfo(ctx context.Context, workspaceID, productID, cityID string) (_ *models.ProductInfo, err error) {
+	defer observeQuery("GetProductInfo", time.Now(), &err)
+	return r.next.GetProductInfo(ctx, workspaceID, productID, cityID)
+}
+
+func (r *repository) SaveSnapshot(ctx context.Context, productInfo *models.ProductInfo) (err error) {
+	defer observeQuery("SaveSnapshot", time.Now(), &err)
+	return r.next.SaveSnapshot(ctx, productInfo)
+}
+
+func (r *repository) ListProductIDs(ctx context.Context, workspaceID, cityID string, filter models.ProductFilter) (_ []string, err error) {
+	defer observeQuery("ListProductIDs", time.Now(), &err)
+	return r.next.ListProductIDs(ctx, workspaceID, cityID, filter)
+}
+
+func (r *repository) GetProductSnapshots(ctx context.Context, workspaceID, productID, cityID string, from, to time.Time) (_ []models.ProductInfo, err error) {
+	defer observeQuery("GetProductSnapshots", time.Now(), &err)
+	return r.next.GetProductSnapshots(ctx, workspaceID, productID, cityID, from, to)
+}
+
+func (r *repository) ListProductCities(ctx context.Context, workspaceID, productID string) (_ []string, err error) {
+	defer observeQuery("ListProductCities", time.Now(), &err)
+	return r.next.ListProductCities(ctx, workspaceID, productID)
+}
+
+func (r *repository) LastIngestionTime(ctx context.Context) (_ *time.Time, err error) {
+	defer observeQuery("LastIngestionTime", time.Now(), &err)
+	return r.next.LastIngestionTime(ctx)
+}
+
+func (r *repository) SaveQuarantinedOffers(ctx context.Context, offers []models.QuarantinedOffer) (err error) {
+	defer observeQuery("SaveQuarantinedOffers", time.Now(), &err)
+	return r.next.SaveQuarantinedOffers(ctx, offers)
+}
+
+func (r *repository) GetQuarantinedOffers(ctx context.Context, workspaceID, productID string, limit int) (_ []models.QuarantinedOffer, err error) {
+	defer observeQuery("GetQuarantinedOffers", time.Now(), &err)
+	return r.next.GetQuarantinedOffers(ctx, workspaceID, productID, limit)
+}
+
+func (r *repository) CreateJob(ctx context.Context, job *models.Job) (err error) {
+	defer observeQuery("CreateJob", time.Now(), &err)
+	return r.next.CreateJob(ctx, job)
+}
+
+func (r *repository) ClaimJob(ctx context.Context, lease time.Duration) (_ *models.Job, err error) {
+	defer observeQuery("ClaimJob", time.Now(), &err)
+	return r.next.ClaimJob(ctx, lease)
+}
+
+func (r *repository) UpdateJob(ctx context.Context, job *models.Job) (err error) {
+	defer observeQuery("UpdateJob", time.Now(), &err)
+	return r.next.UpdateJob(ctx, job)
+}
+
+func (r *repository) GetJob(ctx context.Context, workspaceID string, id int64) (_ *models.Job, err error) {
+	defer observeQuery("GetJob", time.Now(), &err)
+	return r.next.GetJob(ctx, workspaceID, id)
+}
+
+func (r *repository) ExtendJobLease(ctx context.Context, job *models.Job, until time.Time) (err error) {
+	defer observeQuery("ExtendJobLease", time.Now(), &err)
+	return r.next.ExtendJobLease(ctx, job, until)
+}
+
+func (r *repository) QueueStats(ctx context.Context, now time.Time) (_ *models.QueueStats, err error) {
+	defer observeQuery("QueueStats", time.Now(), &err)
+	return r.next.QueueStats(ctx, now)
+}
+
+func (r *repository) SaveWatchlistEntry(ctx context.Context, entry *models.WatchlistEntry) (err error) {
+	defer observeQuery("SaveWatchlistEntry", time.Now(), &err)
+	return r.next.SaveWatchlistEntry(ctx, entry)
+}
+
+func (r *repository) GetWatchlist(ctx context.Context, workspaceID string, tags []string) (_ []models.WatchlistEntry, err error) {
+	defer observeQuery("GetWatchlist", time.Now(), &err)
+	return r.next.GetWatchlist(ctx, workspaceID, tags)
+}
+
+func (r *repository) GetWatchlistEntry(ctx context.Context, workspaceID, productID string) (_ *models.WatchlistEntry, err error) {
+	defer observeQuery("GetWatchlistEntry", time.Now(), &err)
+	return r.next.GetWatchlistEntry(ctx, workspaceID, productID)
+}
+
+func (r *repository) DeleteWatchlistEntry(ctx context.Context, workspaceID, productID string) (_ bool, err error) {
+	defer observeQuery("DeleteWatchlistEntry", time.Now(), &err)
+	return r.next.DeleteWatchlistEntry(ctx, workspaceID, productID)
+}
+
+func (r *repository) ExportPriceHistory(ctx context.Context, workspaceID string, filter models.HistoryFilter, fn func(models.PriceHistory) error) (err error) {
+	defer observeQuery("ExportPriceHistory", time.Now(), &err)
+	return r.next.ExportPriceHistory(ctx, workspaceID, filter, fn)
+}
+
+func (r *repository) Purge(ctx context.Context, before time.Time, dryRun bool) (_ *models.PurgeResult, err error) {
+	defer observeQuery("Purge", time.Now(), &err)
+	return r.next.Purge(ctx, before, dryRun)
+}
+
+func (r *repository) CreateWorkspace(ctx context.Context, workspace *models.Workspace) (_ bool, err error) {
+	defer observeQuery("CreateWorkspace", time.Now(), &err)
+	return r.next.CreateWorkspace(ctx, workspace)
+}
+
+func (r *repository) GetWorkspace(ctx context.Context, id string) (_ *models.Workspace, err error) {
+	defer observeQuery("GetWorkspace", time.Now(), &err)
+	return r.next.GetWorkspace(ctx, id)
+}
+
+func (r *repository) ListWorkspaces(ctx context.Context) (_ []models.Workspace, err error) {
+	defer observeQuery("ListWorkspaces", time.Now(), &err)
+	return r.next.ListWorkspaces(ctx)
+}
+
+func (r *repository) UpdateWorkspace(ctx context.Context, workspace *models.Workspace) (_ bool, err error) {
+	defer observeQuery("UpdateWorkspace", time.Now(), &err)
+	return r.next.UpdateWorkspace(ctx, workspace)
+}
+
+func (r *repository) CreateAPIKey(ctx context.Context, key *models.APIKey) (err error) {
+	defer observeQuery("CreateAPIKey", time.Now(), &err)
+	return r.next.CreateAPIKey(ctx, key)
+}
+
+func (r *repository) GetAPIKeyByHash(ctx context.Context, hash string) (_ *models.APIKey, err error) {
+	defer observeQuery("GetAPIKeyByHash", time.Now(), &err)
+	return r.next.GetAPIKeyByHash(ctx, hash)
+}
+
+func (r *repository) ListAPIKeys(ctx context.Context, workspaceID string) (_ []models.APIKey, err error) {
+	defer observeQuery("ListAPIKeys", time.Now(), &err)
+	return r.next.ListAPIKeys(ctx, workspaceID)
+}
+
+func (r *repository) RevokeAPIKey(ctx context.Context, workspaceID string, id int64, at time.Time) (_ bool, err error) {
+	defer observeQuery("RevokeAPIKey", time.Now(), &err)
+	return r.next.RevokeAPIKey(ctx, workspaceID, id, at)
+}
+
+func (r *repository) TouchAPIKey(ctx context.Context, id int64, at time.Time) (err error) {
+	defer observeQuery("TouchAPIKey", time.Now(), &err)
+	return r.next.TouchAPIKey(ctx, id, at)
+}
+
+func (r *repository) TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (_ bool, _ float64, err error) {
+	defer observeQuery("TakeToken", time.Now(), &err)
+	return r.next.TakeToken(ctx, key, rate, burst, now)
+}
+
+func (r *repository) IncrementQuota(ctx context.Context, key string, day time.Time, limit int) (_ int, _ bool, err error) {
+	defer observeQuery("IncrementQuota", time.Now(), &err)
+	return r.next.IncrementQuota(ctx, key, day, limit)
+}
+
+func (r *repository) Migrate(ctx context.Context) (_ int, err error) {
+	defer observeQuery("Migrate", time.Now(), &err)
+	return r.next.Migrate(ctx)
+}
+
+func (r *repository) PendingMigrations(ctx context.Context) (_ []int, err error) {
+	defer observeQuery("PendingMigrations", time.Now(), &err)
+	return r.next.PendingMigrations(ctx)
+}
+
+func (r *repository) HealthCheck(ctx context.Context) (err error) {
+	defer observeQuery("HealthCheck", time.Now(), &err)
+	return r.next.HealthCheck(ctx)
+}
+
+func (r *repository) Close() error {
+	return r.next.Close()
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+)
+
+// service измеряет загрузку и анализ; остальные методы передаются как есть
+type service struct {
+	ports.Service
+}
+
+// InstrumentService оборачивает сервис сбором метрик загрузки и анализа
+func InstrumentService(next ports.Service) ports.Service {
+	return &service{Service: next}
+}
+
+func (s *service) SaveKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (*models.ProductAnalysis, error) {
+	start := time.Now()
+	analysis, err := s.Service.SaveKaspiData(ctx, workspaceID, request)
+	analysisDuration.WithLabelValues("ingest").Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		ingestions.WithLabelValues(workspaceID, "error").Inc()
+		return nil, err
+	}
+	ingestions.WithLabelValues(workspaceID, "ok").Inc()
+	productIngestions.WithLabelValues(workspaceID, trackedProducts.label(workspaceID, request.ProductID)).Inc()
+	lastIngestion.SetToCurrentTime()
+	offersPerPayload.Observe(float64(len(request.Offers.Offers)))
+	dumpingDetections.Add(float64(len(analysis.DumpingSellers)))
+	return analysis, nil
+}
+
+func (s *service) AnalyzeProduct(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductAnalysis, error) {
+	defer observeAnalysis("analyze", time.Now())
+	return s.Service.AnalyzeProduct(ctx, workspaceID, productID, cityID)
+}
+
+func (s *service) AnalyzeProducts(ctx context.Context, workspaceID string, request *models.BulkAnalysisRequest) (*models.BulkAnalysisResult, error) {
+	defer observeAnalysis("bulk_analyze", time.Now())
+	return s.Service.AnalyzeProducts(ctx, workspaceID, request)
+}
+
+func (s *service) CompareCities(ctx context.Context, workspaceID, productID string) (*models.CityComparison, error) {
+	defer observeAnalysis("compare_cities", time.Now())
+	return s.Service.CompareCities(ctx, workspaceID, productID)
+}
+
+func (s *service) Backtest(ctx context.Context, workspaceID string, request *models.BacktestRequest) (*models.BacktestReport, error) {
+	defer observeAnalysis("backtest", time.Now())
+	return s.Service.Backtest(ctx, workspaceID, request)
+}
+
+func observeAnalysis(operation string, start time.Time) {
+	analysisDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	coreerrs "Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/requestid"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// Максимальный размер JSON-тела запроса, который проверяется по схеме
+	maxValidatedBody = 32 << 20
+	// Ответы больше этого размера по схеме не проверяются
+	maxValidatedResponse = 4 << 20
+)
+
+// ValidationError - ответ 400 с ошибками по полям
+type ValidationError struct {
+	Error     string       `json:"error"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Fields    []FieldError `json:"fields"`
+}
+
+// Validator проверяет запросы по спецификации до обработчика (некорректные получают 400),
+// а JSON-ответы - после него (несоответствия только пишутся в лог)
+type Validator struct {
+	doc               *Document
+	validateRequests  bool
+	validateResponses bool
+}
+
+func NewValidator(doc *Document, validateRequests, validateResponses bool) *Validator {
+	return &Validator{doc: doc, validateRequests: validateRequests, validateResponses: validateResponses}
+}
+
+// Middleware - middleware для mux.Router.Use: маршрут уже выбран, поэтому известен шаблон пути
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		operation := v.doc.Operation(template, r.Method)
+		if operation == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if v.validateRequests {
+			if errs := v.ValidateRequest(r, operation); len(errs) > 0 {
+				writeValidationError(w, r, errs)
+				return
+			}
+		}
+
+		if !v.validateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if errs := v.validateResponse(operation, recorder); len(errs) > 0 {
+			slog.WarnContext(r.Context(), "response does not match OpenAPI spec",
+				"status", recorder.status, "method", r.Method, "route", template, "errors", formatErrors(errs))
+		}
+	})
+}
+
+// ValidateRequest проверяет параметры пути и запроса и JSON-тело. Тело читается и подменяется копией.
+func (v *Validator) ValidateRequest(r *http.Request, operation *Operation) []FieldError {
+	var errs []FieldError
+
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	for _, param := range operation.Parameters {
+		var raw string
+		var present bool
+		switch param.In {
+		case "path":
+			raw, present = vars[param.Name]
+		case "query":
+			present = query.Has(param.Name)
+			raw = query.Get(param.Name)
+		default:
+			continue
+		}
+
+		field := param.In + "." + param.Name
+		if !present {
+			if param.Required {
+				errs = append(errs, FieldError{Field: field, Message: "is required"})
+			}
+			continue
+		}
+		v.doc.validate(param.Schema, v.coerce(param.Schema, raw), field, &errs)
+	}
+
+	if body := operation.RequestBody; body != nil {
+		if media, ok := body.Content["application/json"]; ok && isJSONRequest(r) {
+			errs = append(errs, v.validateBody(r, body.Required, media.Schema)...)
+		}
+	}
+
+	return errs
+}
+
+func (v *Validator) validateBody(r *http.Request, required bool, schema *Schema) []FieldError {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBody+1))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return []FieldError{{Field: "body", Message: "failed to read body"}}
+	}
+	if len(data) > maxValidatedBody {
+		return []FieldError{{Field: "body", Message: "is too large"}}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if required {
+			return []FieldError{{Field: "body", Message: "is required"}}
+		}
+		return nil
+	}
+
+	value, err := decodeJSON(data)
+	if err != nil {
+		return []FieldError{{Field: "body", Message: "invalid JSON: " + err.Error()}}
+	}
+
+	var errs []FieldError
+	v.doc.validate(schema, value, "body", &errs)
+	return errs
+}
+
+func (v *Validator) validateResponse(operation *Operation, recorder *responseRecorder) []FieldError {
+	if recorder.overflow {
+		return nil
+	}
+	return v.ValidateResponse(operation, recorder.status, recorder.Header(), recorder.body.Bytes())
+}
+
+// ValidateResponse проверяет статус и JSON-тело ответа; ответы в других форматах не проверяются
+func (v *Validator) ValidateResponse(operation *Operation, status int, header http.Header, body []byte) []FieldError {
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return nil
+	}
+
+	response, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		if response, ok = operation.Responses["default"]; !ok {
+			return []FieldError{{Field: "status", Message: "undocumented status " + strconv.Itoa(status)}}
+		}
+	}
+	media, ok := response.Content["application/json"]
+	if !ok {
+		return []FieldError{{Field: "content-type", Message: "undocumented JSON response"}}
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return []FieldError{{Field: "response", Message: "invalid JSON: " + err.Error()}}
+	}
+
+	var errs []FieldError
+	v.doc.validate(media.Schema, value, "response", &errs)
+	return errs
+}
+
+// coerce приводит строковое значение параметра к типу схемы; неприводимое остается строкой и не пройдет проверку
+func (v *Validator) coerce(schema *Schema, raw string) interface{} {
+	schema = v.doc.resolve(schema)
+	if schema == nil {
+		return raw
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case "boolean":
+		if value, err := strconv.ParseBool(raw); err == nil {
+			return value
+		}
+	}
+	return raw
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// isJSONRequest - запрос с JSON-телом; без Content-Type тело тоже считается JSON, как и в обработчиках
+func isJSONRequest(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func writeValidationError(w http.ResponseWriter, r *http.Request, errs []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(ValidationError{
+		Error:     "Request validation failed",
+		Code:      string(coreerrs.KindValidation),
+		RequestID: requestid.FromContext(r.Context()),
+		Fields:    errs,
+	})
+}
+
+func formatErrors(errs []FieldError) string {
+	parts := make([]string, len(errs))
+	for i, e := range errs {
+		parts[i] = e.Field + " " + e.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// responseRecorder передает ответ клиенту и сохраняет копию для проверки
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if !r.overflow {
+		if r.body.Len()+len(data) > maxValidatedResponse {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(data)
+		}
+	}
+	return r.ResponseWriter.Write(data)
+}
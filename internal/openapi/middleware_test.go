@@ -0,0 +1,283 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// testSpec - небольшая спецификация с теми же конструкциями, что и openapi.json
+const testSpec = `{
+  "paths": {
+    "/items/{id}": {
+      "post": {
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "schema": {"type": "integer", "minimum": 1}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "maximum": 100}},
+          {"name": "dry_run", "in": "query", "schema": {"type": "boolean"}},
+          {"name": "format", "in": "query", "schema": {"type": "string", "enum": ["json", "csv"]}},
+          {"name": "city", "in": "query", "required": true, "schema": {"type": "string", "pattern": "^\\d+$"}}
+        ],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+        "responses": {
+          "200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+          "204": {},
+          "default": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Item": {
+        "type": "object",
+        "required": ["name", "price"],
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "price": {"type": "number", "minimum": 0},
+          "count": {"type": "integer"},
+          "tags": {"type": "array", "nullable": true, "items": {"type": "string"}},
+          "updated_at": {"type": "string", "format": "date-time"},
+          "attributes": {"type": "object", "additionalProperties": {"type": "number"}}
+        }
+      },
+      "Error": {
+        "type": "object",
+        "required": ["error", "code"],
+        "properties": {"error": {"type": "string"}, "code": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func newTestValidator(t *testing.T) (*Validator, *Operation) {
+	t.Helper()
+
+	var doc Document
+	if err := json.Unmarshal([]byte(testSpec), &doc); err != nil {
+		t.Fatalf("test spec: %v", err)
+	}
+	return NewValidator(&doc, true, true), doc.Operation("/items/{id:[0-9]+}", "POST")
+}
+
+func TestCoerce(t *testing.T) {
+	v, _ := newTestValidator(t)
+
+	tests := []struct {
+		schema *Schema
+		raw    string
+		want   interface{}
+	}{
+		{schema: &Schema{Type: "integer"}, raw: "42", want: json.Number("42")},
+		{schema: &Schema{Type: "number"}, raw: "1.5", want: json.Number("1.5")},
+		{schema: &Schema{Type: "integer"}, raw: "many", want: "many"},
+		{schema: &Schema{Type: "boolean"}, raw: "true", want: true},
+		{schema: &Schema{Type: "boolean"}, raw: "0", want: false},
+		{schema: &Schema{Type: "boolean"}, raw: "maybe", want: "maybe"},
+		{schema: &Schema{Type: "string"}, raw: "42", want: "42"},
+		{schema: &Schema{Ref: "#/components/schemas/Missing"}, raw: "42", want: "42"},
+		{schema: nil, raw: "42", want: "42"},
+	}
+	for _, tt := range tests {
+		if got := v.coerce(tt.schema, tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("coerce(%+v, %q) = %#v, want %#v", tt.schema, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRequestParameters(t *testing.T) {
+	v, operation := newTestValidator(t)
+
+	tests := []struct {
+		name string
+		id   string
+		path string
+		want []FieldError
+	}{
+		{name: "valid", id: "7", path: "/items/7?city=750000000&limit=10&dry_run=true&format=csv"},
+		{
+			name: "required query",
+			id:   "7",
+			path: "/items/7",
+			want: []FieldError{{Field: "query.city", Message: "is required"}},
+		},
+		{
+			name: "types, bounds, enum and pattern",
+			id:   "0",
+			path: "/items/0?city=almaty&limit=500&dry_run=maybe&format=xml",
+			want: []FieldError{
+				{Field: "path.id", Message: "must be at least 1"},
+				{Field: "query.limit", Message: "must be at most 100"},
+				{Field: "query.dry_run", Message: "must be a boolean"},
+				{Field: "query.format", Message: "must be one of json, csv"},
+				{Field: "query.city", Message: `must match ^\d+$`},
+			},
+		},
+		{
+			name: "not a number",
+			id:   "7",
+			path: "/items/7?city=1&limit=ten",
+			want: []FieldError{{Field: "query.limit", Message: "must be a integer"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.path, strings.NewReader(`{"name":"TV","price":1}`))
+			r = mux.SetURLVars(r, map[string]string{"id": tt.id})
+			if got := v.ValidateRequest(r, operation); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors:\ngot  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBody(t *testing.T) {
+	v, operation := newTestValidator(t)
+	schema := operation.RequestBody.Content["application/json"].Schema
+
+	tests := []struct {
+		name     string
+		body     string
+		required bool
+		want     []FieldError
+	}{
+		{name: "valid", body: `{"name":"TV","price":179990,"count":2,"tags":null,"updated_at":"2024-05-20T10:00:00Z","attributes":{"weight":12.5}}`},
+		{name: "empty optional", body: " "},
+		{name: "empty required", body: "", required: true, want: []FieldError{{Field: "body", Message: "is required"}}},
+		{name: "invalid JSON", body: `{"name":`, want: []FieldError{{Field: "body", Message: "invalid JSON: unexpected EOF"}}},
+		{name: "not an object", body: `[]`, want: []FieldError{{Field: "body", Message: "must be an object"}}},
+		{
+			name: "missing required fields",
+			body: `{"count":1}`,
+			want: []FieldError{
+				{Field: "body.name", Message: "is required"},
+				{Field: "body.price", Message: "is required"},
+			},
+		},
+		{
+			name: "nested field errors in name order",
+			body: `{"updated_at":"yesterday","tags":["tv",5],"price":-1,"name":"","extra":true,"count":1.5,"attributes":{"weight":"heavy"}}`,
+			want: []FieldError{
+				{Field: "body.attributes.weight", Message: "must be a number"},
+				{Field: "body.count", Message: "must be a integer"},
+				{Field: "body.extra", Message: "unknown field"},
+				{Field: "body.name", Message: "must be at least 1 characters"},
+				{Field: "body.price", Message: "must be at least 0"},
+				{Field: "body.tags[1]", Message: "must be a string"},
+				{Field: "body.updated_at", Message: "must be an RFC3339 date-time"},
+			},
+		},
+		{name: "null for non-nullable", body: `{"name":null,"price":1}`, want: []FieldError{{Field: "body.name", Message: "must not be null"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/items/1", strings.NewReader(tt.body))
+			got := v.validateBody(r, tt.required, schema)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors:\ngot  %+v\nwant %+v", got, tt.want)
+			}
+
+			// Тело остается доступным обработчику
+			if body, _ := io.ReadAll(r.Body); string(body) != tt.body {
+				t.Errorf("body after validation %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	v, operation := newTestValidator(t)
+
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        []FieldError
+	}{
+		{name: "valid", status: http.StatusOK, contentType: "application/json; charset=utf-8", body: `{"name":"TV","price":1}`},
+		{name: "empty body", status: http.StatusNoContent, contentType: "application/json"},
+		{name: "not JSON", status: http.StatusOK, contentType: "text/csv", body: "name,price\n"},
+		{name: "default response", status: http.StatusConflict, contentType: "application/json", body: `{"error":"exists","code":"conflict"}`},
+		{
+			name:        "field errors",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"name":"TV","price":"free"}`,
+			want:        []FieldError{{Field: "response.price", Message: "must be a number"}},
+		},
+		{
+			name:        "default response field errors",
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+			body:        `{"error":"boom"}`,
+			want:        []FieldError{{Field: "response.code", Message: "is required"}},
+		},
+		{
+			name:        "undocumented JSON",
+			status:      http.StatusNoContent,
+			contentType: "application/json",
+			body:        `{}`,
+			want:        []FieldError{{Field: "content-type", Message: "undocumented JSON response"}},
+		},
+		{
+			name:        "invalid JSON",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"name"`,
+			want:        []FieldError{{Field: "response", Message: "invalid JSON: unexpected EOF"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Content-Type": {tt.contentType}}
+			if got := v.ValidateResponse(operation, tt.status, header, []byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors:\ngot  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateResponseUndocumentedStatus(t *testing.T) {
+	v, operation := newTestValidator(t)
+	delete(operation.Responses, "default")
+
+	got := v.ValidateResponse(operation, http.StatusTeapot, http.Header{"Content-Type": {"application/json"}}, []byte(`{}`))
+	want := []FieldError{{Field: "status", Message: "undocumented status 418"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors %+v, want %+v", got, want)
+	}
+}
+
+func TestMiddlewareWritesValidationError(t *testing.T) {
+	v, _ := newTestValidator(t)
+	called := false
+	router := mux.NewRouter()
+	router.HandleFunc("/items/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) { called = true }).Methods("POST")
+	router.Use(v.Middleware)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/items/7?city=1", strings.NewReader(`{"name":"TV"}`)))
+
+	if called {
+		t.Error("handler called for an invalid request")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", w.Code)
+	}
+	var response ValidationError
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("response: %v", err)
+	}
+	want := []FieldError{{Field: "body.price", Message: "is required"}}
+	if response.Error != "Request validation failed" || !reflect.DeepEqual(response.Fields, want) {
+		t.Errorf("response %+v, want fields %+v", response, want)
+	}
+}
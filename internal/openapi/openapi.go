@@ -0,0 +1,101 @@
+// Package openapi хранит спецификацию OpenAPI 3 для HTTP API и проверяет по ней запросы и ответы.
+// Спецификация openapi.json поддерживается вручную вместе с HTTPHandler.RegisterRoutes:
+// при запуске сервер предупреждает о маршрутах, которых нет в спецификации.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Spec возвращает спецификацию в JSON
+func Spec() []byte {
+	return spec
+}
+
+type Document struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Parameters  []Parameter          `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Content map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Load разбирает встроенную спецификацию
+func Load() (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("invalid openapi.json: %w", err)
+	}
+	return &doc, nil
+}
+
+// routeVariable убирает регулярные выражения mux из шаблона: /jobs/{id:[0-9]+} -> /jobs/{id}
+var routeVariable = regexp.MustCompile(`\{(\w+):[^}]*\}`)
+
+// Operation находит операцию для шаблона пути mux и метода
+func (d *Document) Operation(pathTemplate, method string) *Operation {
+	item, ok := d.Paths[routeVariable.ReplaceAllString(pathTemplate, "{$1}")]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+// Undocumented возвращает маршруты роутера, которых нет в спецификации
+func (d *Document) Undocumented(router *mux.Router) []string {
+	var missing []string
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"GET"}
+		}
+		for _, method := range methods {
+			if d.Operation(path, method) == nil {
+				missing = append(missing, method+" "+path)
+			}
+		}
+		return nil
+	})
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,224 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema - подмножество JSON Schema из OpenAPI 3.0, которое используется в спецификации
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	once        sync.Once
+	pattern     *regexp.Regexp
+	extra       *Schema // схема дополнительных свойств
+	extraClosed bool    // additionalProperties: false
+}
+
+// FieldError - ошибка конкретного поля: body.offers.offers[0].price, query.city, path.id
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (s *Schema) compile() {
+	s.once.Do(func() {
+		if s.Pattern != "" {
+			s.pattern = regexp.MustCompile(s.Pattern)
+		}
+		raw := strings.TrimSpace(string(s.AdditionalProperties))
+		switch {
+		case raw == "false":
+			s.extraClosed = true
+		case strings.HasPrefix(raw, "{"):
+			s.extra = &Schema{}
+			if err := json.Unmarshal(s.AdditionalProperties, s.extra); err != nil {
+				panic(fmt.Sprintf("openapi: invalid additionalProperties: %v", err))
+			}
+		}
+	})
+}
+
+// validate проверяет значение, декодированное json.Decoder с UseNumber
+func (d *Document) validate(schema *Schema, value interface{}, field string, errs *[]FieldError) {
+	schema = d.resolve(schema)
+	if schema == nil {
+		return
+	}
+	schema.compile()
+
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, part := range schema.AllOf {
+		d.validate(part, value, field, errs)
+	}
+	if len(schema.OneOf) > 0 {
+		d.validateOneOf(schema.OneOf, value, field, errs)
+	}
+
+	if value == nil {
+		if schema.Type != "" && !schema.Nullable {
+			fail("must not be null")
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		d.validateObject(schema, object, field, errs)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		for i, item := range items {
+			d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), errs)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if schema.MinLength != nil && len([]rune(text)) < *schema.MinLength {
+			fail("must be at least %d characters", *schema.MinLength)
+		}
+		if schema.pattern != nil && !schema.pattern.MatchString(text) {
+			fail("must match %s", schema.Pattern)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				fail("must be an RFC3339 date-time")
+			}
+		}
+	case "number", "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			fail("must be a %s", schema.Type)
+			return
+		}
+		v, err := number.Float64()
+		if err != nil || (schema.Type == "integer" && v != math.Trunc(v)) {
+			fail("must be a %s", schema.Type)
+			return
+		}
+		if schema.Minimum != nil && v < *schema.Minimum {
+			fail("must be at least %g", *schema.Minimum)
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			fail("must be at most %g", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		fail("must be one of %s", enumList(schema.Enum))
+	}
+}
+
+func (d *Document) validateObject(schema *Schema, object map[string]interface{}, field string, errs *[]FieldError) {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			*errs = append(*errs, FieldError{Field: join(field, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := schema.Properties[name]; ok {
+			d.validate(property, object[name], join(field, name), errs)
+			continue
+		}
+		switch {
+		case schema.extraClosed:
+			*errs = append(*errs, FieldError{Field: join(field, name), Message: "unknown field"})
+		case schema.extra != nil:
+			d.validate(schema.extra, object[name], join(field, name), errs)
+		}
+	}
+}
+
+// validateOneOf принимает значение, подходящее хотя бы под одну схему, иначе - ошибки ближайшей
+func (d *Document) validateOneOf(schemas []*Schema, value interface{}, field string, errs *[]FieldError) {
+	var best []FieldError
+	for i, schema := range schemas {
+		var candidate []FieldError
+		d.validate(schema, value, field, &candidate)
+		if len(candidate) == 0 {
+			return
+		}
+		if i == 0 || len(candidate) < len(best) {
+			best = candidate
+		}
+	}
+	*errs = append(*errs, best...)
+}
+
+func (d *Document) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, value := range enum {
+		values[i] = fmt.Sprint(value)
+	}
+	return strings.Join(values, ", ")
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
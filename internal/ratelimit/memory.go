@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Как часто MemoryStore удаляет полные корзины и квоты прошедших суток
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	rate      float64
+	burst     int
+}
+
+type quotaKey struct {
+	key string
+	day time.Time
+}
+
+// MemoryStore хранит состояние в памяти процесса: при нескольких репликах у каждой свои ограничения
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	quotas  map[quotaKey]int
+	sweptAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		quotas:  make(map[quotaKey]int),
+	}
+}
+
+func (s *MemoryStore) TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.rate, b.burst = rate, burst
+	b.tokens = b.refill(now)
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return false, b.tokens, nil
+	}
+	b.tokens--
+	return true, b.tokens, nil
+}
+
+func (s *MemoryStore) IncrementQuota(ctx context.Context, key string, day time.Time, limit int) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := quotaKey{key: key, day: day}
+	if s.quotas[k] >= limit {
+		return s.quotas[k], false, nil
+	}
+	s.quotas[k]++
+	return s.quotas[k], true, nil
+}
+
+// refill - число токенов к моменту now
+func (b *bucket) refill(now time.Time) float64 {
+	elapsed := max(0, now.Sub(b.updatedAt).Seconds())
+	return min(float64(b.burst), b.tokens+elapsed*b.rate)
+}
+
+// sweep удаляет заполненные корзины (они ничем не отличаются от новых) и квоты прошедших суток
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < sweepInterval {
+		return
+	}
+	s.sweptAt = now
+
+	for key, b := range s.buckets {
+		if b.refill(now) >= float64(b.burst) {
+			delete(s.buckets, key)
+		}
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	for k := range s.quotas {
+		if k.day.Before(today) {
+			delete(s.quotas, k)
+		}
+	}
+}
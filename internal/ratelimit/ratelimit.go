@@ -0,0 +1,133 @@
+// Package ratelimit ограничивает частоту запросов клиентов (token bucket) и их число за сутки.
+//
+// Клиент - API ключ или IP адрес. У каждого клиента одна общая корзина для маршрутов без своих
+// ограничений и отдельная корзина для каждого маршрута со своим ограничением. Суточная квота
+// считается по UTC и сбрасывается в полночь.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"Mini-Quicko/internal/logging"
+)
+
+// Limit - ограничение маршрута: Rate запросов в секунду с запасом Burst и не более Daily запросов
+// в сутки. Нулевые Rate и Daily означают, что ограничение не задано.
+type Limit struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+	Daily int     `mapstructure:"daily"`
+}
+
+// Store хранит состояние корзин и квот. Реализации: MemoryStore для одной реплики
+// и PostgresRepository для нескольких реплик с общим состоянием.
+type Store interface {
+	// TakeToken забирает токен из корзины key; если токена нет, возвращает false и текущий остаток
+	TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (allowed bool, tokens float64, err error)
+	// IncrementQuota учитывает запрос в квоте key за сутки day, если лимит еще не исчерпан
+	IncrementQuota(ctx context.Context, key string, day time.Time, limit int) (used int, allowed bool, err error)
+}
+
+// Decision - результат проверки запроса
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	Reason     string // "rate" или "quota" при отказе
+	Daily      int    // суточная квота маршрута, 0 - без квоты
+	Used       int    // запросов за сутки с учетом текущего
+}
+
+// Limiter проверяет запросы по ограничениям из конфигурации
+type Limiter struct {
+	store    Store
+	defaults Limit
+	routes   map[string]Limit
+}
+
+// NewLimiter создает ограничитель. Ключи routes - "METHOD /path" или "/path" в виде шаблона
+// маршрута mux без регулярных выражений ("GET /jobs/{id}"), регистр не важен.
+func NewLimiter(store Store, defaults Limit, routes map[string]Limit) *Limiter {
+	normalized := make(map[string]Limit, len(routes))
+	for route, limit := range routes {
+		normalized[normalizeRoute(route)] = withBurst(limit)
+	}
+	return &Limiter{store: store, defaults: withBurst(defaults), routes: normalized}
+}
+
+// Allow проверяет запрос клиента client к маршруту method path (шаблон маршрута mux).
+// Ошибки хранилища не блокируют запросы: ограничение в этом случае пропускается.
+func (l *Limiter) Allow(ctx context.Context, client, method, path string, now time.Time) Decision {
+	route, limit, own := l.lookup(method, path)
+
+	// Маршруты без своей частоты делят общую корзину клиента
+	rate, burst, bucket := l.defaults.Rate, l.defaults.Burst, client
+	if own && limit.Rate > 0 {
+		rate, burst, bucket = limit.Rate, limit.Burst, client+"|"+route
+	}
+	if rate > 0 {
+		allowed, tokens, err := l.store.TakeToken(ctx, bucket, rate, burst, now)
+		if err != nil {
+			slog.WarnContext(ctx, "rate limit store failed, request is not limited", "client", client, logging.Err(err))
+			return Decision{Allowed: true}
+		}
+		if !allowed {
+			return Decision{Reason: "rate", RetryAfter: time.Duration((1 - tokens) / rate * float64(time.Second))}
+		}
+	}
+
+	daily := l.defaults.Daily
+	quotaKey := client
+	if own && limit.Daily > 0 {
+		daily, quotaKey = limit.Daily, client+"|"+route
+	}
+	if daily <= 0 {
+		return Decision{Allowed: true}
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	used, allowed, err := l.store.IncrementQuota(ctx, quotaKey, day, daily)
+	if err != nil {
+		slog.WarnContext(ctx, "rate limit store failed, quota is not checked", "client", client, logging.Err(err))
+		return Decision{Allowed: true}
+	}
+	if !allowed {
+		return Decision{Reason: "quota", Daily: daily, Used: daily, RetryAfter: day.Add(24 * time.Hour).Sub(now)}
+	}
+	return Decision{Allowed: true, Daily: daily, Used: used}
+}
+
+// RetryAfterSeconds - значение заголовка Retry-After, округленное вверх до целых секунд
+func (d Decision) RetryAfterSeconds() int {
+	return max(1, int(math.Ceil(d.RetryAfter.Seconds())))
+}
+
+func (l *Limiter) lookup(method, path string) (string, Limit, bool) {
+	route := normalizeRoute(method + " " + path)
+	if limit, ok := l.routes[route]; ok {
+		return route, limit, true
+	}
+	if limit, ok := l.routes[normalizeRoute(path)]; ok {
+		return route, limit, true
+	}
+	return route, Limit{}, false
+}
+
+var routePattern = regexp.MustCompile(`\{([^}:]+):[^}]*\}`)
+
+// normalizeRoute приводит "GET /jobs/{id:[0-9]+}" к "get /jobs/{id}"
+func normalizeRoute(route string) string {
+	return strings.ToLower(routePattern.ReplaceAllString(strings.TrimSpace(route), "{$1}"))
+}
+
+// withBurst подставляет запас по умолчанию - одна секунда запросов, но не меньше одного
+func withBurst(limit Limit) Limit {
+	if limit.Rate > 0 && limit.Burst <= 0 {
+		limit.Burst = max(1, int(math.Ceil(limit.Rate)))
+	}
+	return limit
+}
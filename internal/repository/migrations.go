@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// migration - версия схемы БД. Примененные версии записываются в schema_migrations,
+// новые изменения схемы добавляются в конец списка и никогда не редактируются задним числом.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// Первые версии повторяют прежний createTables и написаны идемпотентно (IF NOT EXISTS),
+// поэтому на существующей базе они просто помечаются примененными.
+var migrations = []migration{
+	{1, "create price_history", `
+		CREATE TABLE IF NOT EXISTS price_history (
+			id SERIAL PRIMARY KEY,
+			product_id VARCHAR(255) NOT NULL,
+			seller_id VARCHAR(255) NOT NULL,
+			price DECIMAL(10,2) NOT NULL,
+			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(product_id, seller_id, timestamp)
+		)
+	`},
+	{2, "create product_info", `
+		CREATE TABLE IF NOT EXISTS product_info (
+			product_id VARCHAR(255) NOT NULL,
+			seller_id VARCHAR(255) NOT NULL,
+			seller_name VARCHAR(255),
+			price DECIMAL(10,2) NOT NULL,
+			rating DECIMAL(3,2),
+			reviews INTEGER,
+			purchases INTEGER,
+			sku VARCHAR(255),
+			segment DECIMAL(3,1),
+			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (product_id, seller_id, timestamp)
+		)
+	`},
+	// Позиция оффера в выдаче и параметры доставки для модели первого места
+	{3, "add offer position and delivery to product_info", `
+		ALTER TABLE product_info
+			ADD COLUMN IF NOT EXISTS position INTEGER,
+			ADD COLUMN IF NOT EXISTS delivery_type VARCHAR(64),
+			ADD COLUMN IF NOT EXISTS delivery_duration VARCHAR(64),
+			ADD COLUMN IF NOT EXISTS kaspi_delivery BOOLEAN
+	`},
+	// Карантин подозрительных офферов для ручной проверки
+	{4, "create quarantined_offers", `
+		CREATE TABLE IF NOT EXISTS quarantined_offers (
+			id SERIAL PRIMARY KEY,
+			product_id VARCHAR(255) NOT NULL,
+			merchant_id VARCHAR(255) NOT NULL DEFAULT '',
+			price DECIMAL(12,2),
+			reasons TEXT[] NOT NULL,
+			offer JSONB,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_quarantined_offers_product ON quarantined_offers (product_id, created_at DESC);
+	`},
+	// Очередь асинхронной загрузки данных
+	{5, "create ingestion_jobs", `
+		CREATE TABLE IF NOT EXISTS ingestion_jobs (
+			id BIGSERIAL PRIMARY KEY,
+			product_id VARCHAR(255) NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			max_attempts INTEGER NOT NULL,
+			payload JSONB NOT NULL,
+			result JSONB,
+			error TEXT,
+			run_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			finished_at TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_ingestion_jobs_queued ON ingestion_jobs (run_at, id) WHERE status = 'queued';
+	`},
+	// Список отслеживаемых товаров
+	{6, "create watchlist", `
+		CREATE TABLE IF NOT EXISTS watchlist (
+			product_id VARCHAR(255) PRIMARY KEY,
+			tags TEXT[] NOT NULL DEFAULT '{}',
+			priority INTEGER NOT NULL DEFAULT 0,
+			poll_interval_seconds INTEGER NOT NULL DEFAULT 0,
+			own_sku VARCHAR(255) NOT NULL DEFAULT '',
+			notes TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_watchlist_tags ON watchlist USING GIN (tags);
+	`},
+	// Цены на Kaspi различаются по городам. Записи, сохраненные до появления города, относятся к Алматы.
+	{7, "add city dimension", `
+		ALTER TABLE price_history ADD COLUMN IF NOT EXISTS city_id VARCHAR(32) NOT NULL DEFAULT '` + models.DefaultCityID + `';
+		ALTER TABLE product_info ADD COLUMN IF NOT EXISTS city_id VARCHAR(32) NOT NULL DEFAULT '` + models.DefaultCityID + `';
+		ALTER TABLE quarantined_offers ADD COLUMN IF NOT EXISTS city_id VARCHAR(32) NOT NULL DEFAULT '` + models.DefaultCityID + `';
+		ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS cities TEXT[] NOT NULL DEFAULT '{}';
+		CREATE INDEX IF NOT EXISTS idx_product_info_product_city ON product_info (product_id, city_id, timestamp DESC);
+		CREATE INDEX IF NOT EXISTS idx_price_history_product_city ON price_history (product_id, city_id, timestamp DESC);
+	`},
+	// Индексы по времени для выгрузки и очистки старых данных
+	{8, "add timestamp indexes for export and purge", `
+		CREATE INDEX IF NOT EXISTS idx_price_history_timestamp ON price_history (timestamp);
+		CREATE INDEX IF NOT EXISTS idx_product_info_timestamp ON product_info (timestamp);
+	`},
+	// API ключи: хранится только хэш ключа
+	{9, "create api_keys", `
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id BIGSERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			prefix VARCHAR(32) NOT NULL,
+			key_hash CHAR(64) NOT NULL UNIQUE,
+			roles TEXT[] NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_used_at TIMESTAMP,
+			revoked_at TIMESTAMP
+		);
+	`},
+	// Рабочие пространства команд. Существующие данные переходят в пространство по умолчанию;
+	// значение по умолчанию у колонок затем убирается, чтобы запись без пространства была ошибкой.
+	{10, "add workspaces", `
+		CREATE TABLE IF NOT EXISTS workspaces (
+			id VARCHAR(64) PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			own_merchant_id VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO workspaces (id, name) VALUES ('` + models.DefaultWorkspaceID + `', 'Default') ON CONFLICT (id) DO NOTHING;
+		ALTER TABLE price_history ADD COLUMN workspace_id VARCHAR(64) NOT NULL DEFAULT '` + models.DefaultWorkspaceID + `' REFERENCES workspaces (id);
+		ALTER TABLE price_history ALTER COLUMN workspace_id DROP DEFAULT;
+		ALTER TABLE product_info ADD COLUMN workspace_id VARCHAR(64) NOT NULL DEFAULT '` + models.DefaultWorkspaceID + `' REFERENCES workspaces (id);
+		ALTER TABLE product_info ALTER COLUMN workspace_id DROP DEFAULT;
+		ALTER TABLE quarantined_offers ADD COLUMN workspace_id VARCHAR(64) NOT NULL DEFAULT '` + models.DefaultWorkspaceID + `' REFERENCES workspaces (id);
+		ALTER TABLE quarantined_offers ALTER COLUMN workspace_id DROP DEFAULT;
+		ALTER TABLE ingestion_jobs ADD COLUMN workspace_id VARCHAR(64) NOT NULL DEFAULT '` + models.DefaultWorkspaceID + `' REFERENCES workspaces (id);
+		ALTER TABLE ingestion_jobs ALTER COLUMN workspace_id DROP DEFAULT;
+		ALTER TABLE watchlist ADD COLUMN workspace_id VARCHAR(64) NOT NULL DEFAULT '` + models.DefaultWorkspaceID + `' REFERENCES workspaces (id);
+		ALTER TABLE watchlist ALTER COLUMN workspace_id DROP DEFAULT;
+		ALTER TABLE api_keys ADD COLUMN workspace_id VARCHAR(64) NOT NULL DEFAULT '` + models.DefaultWorkspaceID + `' REFERENCES workspaces (id);
+		ALTER TABLE api_keys ALTER COLUMN workspace_id DROP DEFAULT;
+		ALTER TABLE price_history DROP CONSTRAINT IF EXISTS price_history_product_id_seller_id_timestamp_key;
+		ALTER TABLE price_history ADD CONSTRAINT price_history_workspace_product_seller_timestamp_key
+			UNIQUE (workspace_id, product_id, seller_id, timestamp);
+		ALTER TABLE product_info DROP CONSTRAINT IF EXISTS product_info_pkey;
+		ALTER TABLE product_info ADD PRIMARY KEY (workspace_id, product_id, seller_id, timestamp);
+		ALTER TABLE watchlist DROP CONSTRAINT IF EXISTS watchlist_pkey;
+		ALTER TABLE watchlist ADD PRIMARY KEY (workspace_id, product_id);
+		DROP INDEX IF EXISTS idx_product_info_product_city;
+		DROP INDEX IF EXISTS idx_price_history_product_city;
+		DROP INDEX IF EXISTS idx_quarantined_offers_product;
+		CREATE INDEX idx_product_info_workspace_product_city ON product_info (workspace_id, product_id, city_id, timestamp DESC);
+		CREATE INDEX idx_price_history_workspace_product_city ON price_history (workspace_id, product_id, city_id, timestamp DESC);
+		CREATE INDEX idx_quarantined_offers_workspace_product ON quarantined_offers (workspace_id, product_id, created_at DESC);
+		CREATE INDEX idx_api_keys_workspace ON api_keys (workspace_id);
+	`},
+	// Общее состояние ограничения частоты запросов для нескольких реплик (ratelimit.store: postgres)
+	{11, "create rate limit tables", `
+		CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+			key VARCHAR(512) PRIMARY KEY,
+			tokens DOUBLE PRECISION NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS rate_limit_quotas (
+			key VARCHAR(512) NOT NULL,
+			day DATE NOT NULL,
+			count INTEGER NOT NULL,
+			PRIMARY KEY (key, day)
+		);
+	`},
+	// Аренда задачи воркером: задачу с истекшей арендой (реплика упала или зависла) забирает другой воркер
+	{12, "add ingestion job leases", `
+		ALTER TABLE ingestion_jobs ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+		CREATE INDEX IF NOT EXISTS idx_ingestion_jobs_running ON ingestion_jobs (locked_until) WHERE status = 'running';
+	`},
+	// Снимки одного товара в разных городах в одну секунду не конфликтуют: город входит в ключи
+	{13, "add city to product keys", `
+		ALTER TABLE price_history DROP CONSTRAINT IF EXISTS price_history_workspace_product_seller_timestamp_key;
+		ALTER TABLE price_history ADD CONSTRAINT price_history_workspace_product_city_seller_timestamp_key
+			UNIQUE (workspace_id, product_id, city_id, seller_id, timestamp);
+		ALTER TABLE product_info DROP CONSTRAINT IF EXISTS product_info_pkey;
+		ALTER TABLE product_info ADD PRIMARY KEY (workspace_id, product_id, city_id, seller_id, timestamp);
+	`},
+	// Время, когда корзина снова заполнится: заполненные корзины и квоты прошедших суток удаляются
+	{14, "add rate limit expiry", `
+		ALTER TABLE rate_limit_buckets ADD COLUMN IF NOT EXISTS full_at TIMESTAMP;
+		UPDATE rate_limit_buckets SET full_at = updated_at WHERE full_at IS NULL;
+		ALTER TABLE rate_limit_buckets ALTER COLUMN full_at SET NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full_at ON rate_limit_buckets (full_at);
+		CREATE INDEX IF NOT EXISTS idx_rate_limit_quotas_day ON rate_limit_quotas (day);
+	`},
+}
+
+// migrationsLockID - ключ advisory lock, чтобы несколько реплик не применяли миграции одновременно
+const migrationsLockID = 72846193
+
+// Migrate применяет недостающие миграции и возвращает их число
+func (r *PostgresRepository) Migrate(ctx context.Context) (int, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockID); err != nil {
+		return 0, fmt.Errorf("failed to acquire migrations lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationsLockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if err := applyMigration(ctx, conn, m); err != nil {
+			return count, fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+		slog.InfoContext(ctx, "applied migration", "version", m.version, "name", m.name)
+		count++
+	}
+
+	return count, nil
+}
+
+// PendingMigrations возвращает версии миграций, которые еще не применены
+func (r *PostgresRepository) PendingMigrations(ctx context.Context) ([]int, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	applied := map[int]bool{}
+	if exists {
+		var err error
+		if applied, err = appliedVersions(ctx, r.db); err != nil {
+			return nil, err
+		}
+	}
+
+	pending := []int{}
+	for _, m := range migrations {
+		if !applied[m.version] {
+			pending = append(pending, m.version)
+		}
+	}
+	return pending, nil
+}
+
+func appliedVersions(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}) (map[int]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, m migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+		m.version, m.name, time.Now())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
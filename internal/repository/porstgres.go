@@ -3,21 +3,35 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"Mini-Quicko/internal/core/models"
 	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/logging"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type PostgresRepository struct {
 	db *sql.DB
+
+	rateLimitSweptAt atomic.Int64 // время последней очистки ограничений частоты, UnixNano
+}
+
+// PoolConfig - настройки пула соединений; значения передаются в database/sql как есть,
+// 0 соединений или времени - без ограничения (для MaxIdleConns - без простаивающих соединений)
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
-func NewPostgresRepository(connStr string) (ports.Repository, error) {
+func NewPostgresRepository(connStr string, pool PoolConfig) (ports.Repository, error) {
 	var db *sql.DB
 	var err error
 
@@ -25,14 +39,14 @@ func NewPostgresRepository(connStr string) (ports.Repository, error) {
 	for i := 0; i < 10; i++ {
 		db, err = sql.Open("postgres", connStr)
 		if err != nil {
-			log.Printf("Failed to open database (attempt %d): %v", i+1, err)
+			slog.Warn("failed to open database", "attempt", i+1, logging.Err(err))
 			time.Sleep(2 * time.Second)
 			continue
 		}
 
 		err = db.Ping()
 		if err != nil {
-			log.Printf("Failed to ping database (attempt %d): %v", i+1, err)
+			slog.Warn("failed to ping database", "attempt", i+1, logging.Err(err))
 			db.Close()
 			time.Sleep(2 * time.Second)
 			continue
@@ -46,74 +60,37 @@ func NewPostgresRepository(connStr string) (ports.Repository, error) {
 		return nil, fmt.Errorf("failed to connect to database after retries: %w", err)
 	}
 
-	// Create tables if they don't exist
-	if err := createTables(db); err != nil {
-		return nil, fmt.Errorf("failed to create tables: %w", err)
-	}
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
-	log.Println("Successfully connected to PostgreSQL database")
+	slog.Info("connected to PostgreSQL database", "max_open_conns", pool.MaxOpenConns, "max_idle_conns", pool.MaxIdleConns)
 	return &PostgresRepository{db: db}, nil
 }
 
-func createTables(db *sql.DB) error {
-	// Таблица истории цен
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS price_history (
-			id SERIAL PRIMARY KEY,
-			product_id VARCHAR(255) NOT NULL,
-			seller_id VARCHAR(255) NOT NULL,
-			price DECIMAL(10,2) NOT NULL,
-			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(product_id, seller_id, timestamp)
-		)
-	`)
-	if err != nil {
-		return err
-	}
+// sellerColumns - общий список колонок product_info для scanSeller
+const sellerColumns = `seller_id, seller_name, price, rating, reviews, purchases, sku, segment,
+	COALESCE(position, 0), COALESCE(delivery_type, ''), COALESCE(delivery_duration, ''), COALESCE(kaspi_delivery, FALSE), timestamp`
 
-	// Таблица информации о продуктах
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS product_info (
-			product_id VARCHAR(255) NOT NULL,
-			seller_id VARCHAR(255) NOT NULL,
-			seller_name VARCHAR(255),
-			price DECIMAL(10,2) NOT NULL,
-			rating DECIMAL(3,2),
-			reviews INTEGER,
-			purchases INTEGER,
-			sku VARCHAR(255),
-			segment DECIMAL(3,1),
-			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (product_id, seller_id, timestamp)
-		)
-	`)
-	return err
+func scanSeller(rows *sql.Rows) (models.Seller, time.Time, error) {
+	var seller models.Seller
+	var timestamp time.Time
+	err := rows.Scan(&seller.ID, &seller.Name, &seller.Price, &seller.Rating, &seller.Reviews, &seller.Purchases, &seller.SKU, &seller.Segment,
+		&seller.Position, &seller.DeliveryType, &seller.DeliveryDuration, &seller.KaspiDelivery, &timestamp)
+	return seller, timestamp, err
 }
 
-func (r *PostgresRepository) SavePriceHistory(ctx context.Context, history *models.PriceHistory) error {
+func (r *PostgresRepository) GetPriceHistory(ctx context.Context, workspaceID, productID, cityID string, limit int) ([]models.PriceHistory, error) {
 	query := `
-		INSERT INTO price_history (product_id, seller_id, price, timestamp)
-		VALUES ($1, $2, $3, $4)
-	`
-	_, err := r.db.ExecContext(ctx, query,
-		history.ProductID,
-		history.SellerID,
-		history.Price,
-		history.Timestamp,
-	)
-	return err
-}
-
-func (r *PostgresRepository) GetPriceHistory(ctx context.Context, productID string, limit int) ([]models.PriceHistory, error) {
-	query := `
-		SELECT id, product_id, seller_id, price, timestamp
+		SELECT id, workspace_id, product_id, city_id, seller_id, price, timestamp
 		FROM price_history
-		WHERE product_id = $1
+		WHERE workspace_id = $1 AND product_id = $2 AND city_id = $3
 		ORDER BY timestamp DESC
-		LIMIT $2
+		LIMIT $4
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, productID, limit)
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, productID, cityID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +99,7 @@ func (r *PostgresRepository) GetPriceHistory(ctx context.Context, productID stri
 	var history []models.PriceHistory
 	for rows.Next() {
 		var h models.PriceHistory
-		if err := rows.Scan(&h.ID, &h.ProductID, &h.SellerID, &h.Price, &h.Timestamp); err != nil {
+		if err := rows.Scan(&h.ID, &h.WorkspaceID, &h.ProductID, &h.CityID, &h.SellerID, &h.Price, &h.Timestamp); err != nil {
 			return nil, err
 		}
 		history = append(history, h)
@@ -131,30 +108,32 @@ func (r *PostgresRepository) GetPriceHistory(ctx context.Context, productID stri
 	return history, nil
 }
 
-func (r *PostgresRepository) GetProductInfo(ctx context.Context, productID string) (*models.ProductInfo, error) {
+func (r *PostgresRepository) GetProductInfo(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductInfo, error) {
 	query := `
-		SELECT seller_id, seller_name, price, rating, reviews, purchases, sku, segment, timestamp
+		SELECT ` + sellerColumns + `
 		FROM product_info
-		WHERE product_id = $1 AND timestamp = (
-			SELECT MAX(timestamp) FROM product_info WHERE product_id = $1
+		WHERE workspace_id = $1 AND product_id = $2 AND city_id = $3 AND timestamp = (
+			SELECT MAX(timestamp) FROM product_info WHERE workspace_id = $1 AND product_id = $2 AND city_id = $3
 		)
+		ORDER BY position ASC NULLS LAST, seller_id ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, productID)
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, productID, cityID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	productInfo := &models.ProductInfo{
-		ProductID: productID,
-		Sellers:   []models.Seller{},
+		WorkspaceID: workspaceID,
+		ProductID:   productID,
+		CityID:      cityID,
+		Sellers:     []models.Seller{},
 	}
 
 	for rows.Next() {
-		var seller models.Seller
-		var timestamp time.Time
-		if err := rows.Scan(&seller.ID, &seller.Name, &seller.Price, &seller.Rating, &seller.Reviews, &seller.Purchases, &seller.SKU, &seller.Segment, &timestamp); err != nil {
+		seller, timestamp, err := scanSeller(rows)
+		if err != nil {
 			return nil, err
 		}
 		productInfo.Sellers = append(productInfo.Sellers, seller)
@@ -164,7 +143,9 @@ func (r *PostgresRepository) GetProductInfo(ctx context.Context, productID strin
 	return productInfo, nil
 }
 
-func (r *PostgresRepository) SaveProductInfo(ctx context.Context, productInfo *models.ProductInfo) error {
+// SaveSnapshot сохраняет выдачу в product_info и цены продавцов в price_history одной транзакцией:
+// задача очереди, повторенная после ошибки, не оставляет половину снапшота и не дублирует цены
+func (r *PostgresRepository) SaveSnapshot(ctx context.Context, productInfo *models.ProductInfo) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -173,11 +154,14 @@ func (r *PostgresRepository) SaveProductInfo(ctx context.Context, productInfo *m
 
 	for _, seller := range productInfo.Sellers {
 		query := `
-			INSERT INTO product_info (product_id, seller_id, seller_name, price, rating, reviews, purchases, sku, segment, timestamp)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			INSERT INTO product_info (workspace_id, product_id, city_id, seller_id, seller_name, price, rating, reviews, purchases, sku, segment,
+				position, delivery_type, delivery_duration, kaspi_delivery, timestamp)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		`
 		_, err := tx.ExecContext(ctx, query,
+			productInfo.WorkspaceID,
 			productInfo.ProductID,
+			productInfo.CityID,
 			seller.ID,
 			seller.Name,
 			seller.Price,
@@ -186,6 +170,28 @@ func (r *PostgresRepository) SaveProductInfo(ctx context.Context, productInfo *m
 			seller.Purchases,
 			seller.SKU,
 			seller.Segment,
+			seller.Position,
+			seller.DeliveryType,
+			seller.DeliveryDuration,
+			seller.KaspiDelivery,
+			productInfo.Timestamp,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, seller := range productInfo.Sellers {
+		query := `
+			INSERT INTO price_history (workspace_id, product_id, city_id, seller_id, price, timestamp)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`
+		_, err := tx.ExecContext(ctx, query,
+			productInfo.WorkspaceID,
+			productInfo.ProductID,
+			productInfo.CityID,
+			seller.ID,
+			seller.Price,
 			productInfo.Timestamp,
 		)
 		if err != nil {
@@ -196,6 +202,523 @@ func (r *PostgresRepository) SaveProductInfo(ctx context.Context, productInfo *m
 	return tx.Commit()
 }
 
+func (r *PostgresRepository) ListProductIDs(ctx context.Context, workspaceID, cityID string, filter models.ProductFilter) ([]string, error) {
+	query := `
+		SELECT product_id
+		FROM product_info
+		WHERE workspace_id = $1 AND city_id = $2
+		GROUP BY product_id
+		HAVING MAX(timestamp) >= $3
+		ORDER BY product_id
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, cityID, filter.UpdatedSince, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var productIDs []string
+	for rows.Next() {
+		var productID string
+		if err := rows.Scan(&productID); err != nil {
+			return nil, err
+		}
+		productIDs = append(productIDs, productID)
+	}
+
+	return productIDs, rows.Err()
+}
+
+func (r *PostgresRepository) GetProductSnapshots(ctx context.Context, workspaceID, productID, cityID string, from, to time.Time) ([]models.ProductInfo, error) {
+	query := `
+		SELECT ` + sellerColumns + `
+		FROM product_info
+		WHERE workspace_id = $1 AND product_id = $2 AND city_id = $3 AND timestamp >= $4 AND timestamp <= $5
+		ORDER BY timestamp ASC, position ASC NULLS LAST, seller_id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, productID, cityID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Группируем строки в снапшоты по времени сохранения
+	var snapshots []models.ProductInfo
+	for rows.Next() {
+		seller, timestamp, err := scanSeller(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		last := len(snapshots) - 1
+		if last < 0 || !snapshots[last].Timestamp.Equal(timestamp) {
+			snapshots = append(snapshots, models.ProductInfo{
+				WorkspaceID: workspaceID,
+				ProductID:   productID,
+				CityID:      cityID,
+				Sellers:     []models.Seller{},
+				Timestamp:   timestamp,
+			})
+			last++
+		}
+		snapshots[last].Sellers = append(snapshots[last].Sellers, seller)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func (r *PostgresRepository) ListProductCities(ctx context.Context, workspaceID, productID string) ([]string, error) {
+	query := `SELECT DISTINCT city_id FROM product_info WHERE workspace_id = $1 AND product_id = $2 ORDER BY city_id`
+
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cities []string
+	for rows.Next() {
+		var cityID string
+		if err := rows.Scan(&cityID); err != nil {
+			return nil, err
+		}
+		cities = append(cities, cityID)
+	}
+
+	return cities, rows.Err()
+}
+
+// LastIngestionTime - время последнего сохраненного снимка выдачи во всех рабочих пространствах, nil - данных нет
+func (r *PostgresRepository) LastIngestionTime(ctx context.Context) (*time.Time, error) {
+	var last sql.NullTime
+	if err := r.db.QueryRowContext(ctx, `SELECT MAX(timestamp) FROM product_info`).Scan(&last); err != nil {
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+func (r *PostgresRepository) SaveQuarantinedOffers(ctx context.Context, offers []models.QuarantinedOffer) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, offer := range offers {
+		var payload []byte
+		if offer.Offer != nil {
+			if payload, err = json.Marshal(offer.Offer); err != nil {
+				return err
+			}
+		}
+
+		query := `
+			INSERT INTO quarantined_offers (workspace_id, product_id, city_id, merchant_id, price, reasons, offer, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`
+		_, err := tx.ExecContext(ctx, query,
+			offer.WorkspaceID,
+			offer.ProductID,
+			offer.CityID,
+			offer.MerchantID,
+			offer.Price,
+			pq.Array(offer.Reasons),
+			nullableJSON(payload),
+			offer.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) GetQuarantinedOffers(ctx context.Context, workspaceID, productID string, limit int) ([]models.QuarantinedOffer, error) {
+	query := `
+		SELECT id, workspace_id, product_id, city_id, merchant_id, COALESCE(price, 0), reasons, offer, created_at
+		FROM quarantined_offers
+		WHERE workspace_id = $1 AND ($2 = '' OR product_id = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offers := []models.QuarantinedOffer{}
+	for rows.Next() {
+		var offer models.QuarantinedOffer
+		var payload []byte
+		if err := rows.Scan(&offer.ID, &offer.WorkspaceID, &offer.ProductID, &offer.CityID, &offer.MerchantID, &offer.Price, pq.Array(&offer.Reasons), &payload, &offer.CreatedAt); err != nil {
+			return nil, err
+		}
+		if payload != nil {
+			offer.Offer = &models.Offer{}
+			if err := json.Unmarshal(payload, offer.Offer); err != nil {
+				return nil, err
+			}
+		}
+		offers = append(offers, offer)
+	}
+
+	return offers, rows.Err()
+}
+
+const jobColumns = `id, workspace_id, product_id, status, attempts, max_attempts, payload, result, COALESCE(error, ''), run_at, created_at, updated_at, finished_at`
+
+func scanJob(row interface{ Scan(...interface{}) error }) (*models.Job, error) {
+	var job models.Job
+	var result []byte
+	var finishedAt sql.NullTime
+	err := row.Scan(&job.ID, &job.WorkspaceID, &job.ProductID, &job.Status, &job.Attempts, &job.MaxAttempts, &job.Payload, &result,
+		&job.Error, &job.RunAt, &job.CreatedAt, &job.UpdatedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if result != nil {
+		job.Result = &models.ProductAnalysis{}
+		if err := json.Unmarshal(result, job.Result); err != nil {
+			return nil, err
+		}
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return &job, nil
+}
+
+func (r *PostgresRepository) CreateJob(ctx context.Context, job *models.Job) error {
+	query := `
+		INSERT INTO ingestion_jobs (workspace_id, product_id, status, max_attempts, payload, run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id
+	`
+	return r.db.QueryRowContext(ctx, query,
+		job.WorkspaceID,
+		job.ProductID,
+		job.Status,
+		job.MaxAttempts,
+		[]byte(job.Payload),
+		job.RunAt,
+		job.CreatedAt,
+	).Scan(&job.ID)
+}
+
+func (r *PostgresRepository) ClaimJob(ctx context.Context, lease time.Duration) (*models.Job, error) {
+	now := time.Now()
+
+	// Задача, уронившая процесс воркера (panic, OOM), не доходит до ветки окончательной ошибки
+	// в воркере: когда попытки кончились, ее аренда истекает здесь и задача завершается с ошибкой
+	failed := `
+		UPDATE ingestion_jobs
+		SET status = $3, error = 'lease expired after ' || attempts || ' attempts', updated_at = $2, finished_at = $2, locked_until = NULL
+		WHERE status = $1 AND (locked_until IS NULL OR locked_until < $2) AND attempts >= max_attempts
+	`
+	if _, err := r.db.ExecContext(ctx, failed, models.JobStatusRunning, now, models.JobStatusFailed); err != nil {
+		return nil, err
+	}
+
+	// SKIP LOCKED позволяет нескольким воркерам (и репликам) разбирать очередь без блокировок.
+	// Задача в running с истекшей арендой осталась от упавшего воркера и берется повторно,
+	// пока у нее есть попытки; задачи без аренды созданы до ее появления.
+	query := `
+		UPDATE ingestion_jobs
+		SET status = $1, attempts = attempts + 1, updated_at = $2, locked_until = $4
+		WHERE id = (
+			SELECT id FROM ingestion_jobs
+			WHERE (status = $3 AND run_at <= $2)
+				OR (status = $1 AND (locked_until IS NULL OR locked_until < $2) AND attempts < max_attempts)
+			ORDER BY run_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, models.JobStatusRunning, now, models.JobStatusQueued, now.Add(lease)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+func (r *PostgresRepository) UpdateJob(ctx context.Context, job *models.Job) error {
+	var result []byte
+	if job.Result != nil {
+		var err error
+		if result, err = json.Marshal(job.Result); err != nil {
+			return err
+		}
+	}
+
+	// Попытка в условии отсекает воркер, у которого задачу забрали после истечения аренды
+	query := `
+		UPDATE ingestion_jobs
+		SET status = $2, result = $3, error = NULLIF($4, ''), run_at = $5, updated_at = $6, finished_at = $7, locked_until = NULL
+		WHERE id = $1 AND attempts = $8
+	`
+	res, err := r.db.ExecContext(ctx, query,
+		job.ID,
+		job.Status,
+		nullableJSON(result),
+		job.Error,
+		job.RunAt,
+		job.UpdatedAt,
+		job.FinishedAt,
+		job.Attempts,
+	)
+	if err != nil {
+		return err
+	}
+	return checkJobLease(res, job.ID)
+}
+
+func (r *PostgresRepository) ExtendJobLease(ctx context.Context, job *models.Job, until time.Time) error {
+	query := `UPDATE ingestion_jobs SET locked_until = $3 WHERE id = $1 AND attempts = $2 AND status = $4`
+
+	res, err := r.db.ExecContext(ctx, query, job.ID, job.Attempts, until, models.JobStatusRunning)
+	if err != nil {
+		return err
+	}
+	return checkJobLease(res, job.ID)
+}
+
+// checkJobLease сообщает, что задача уже не принадлежит воркеру
+func checkJobLease(res sql.Result, id int64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("ingestion job %d lease was lost to another worker", id)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetJob(ctx context.Context, workspaceID string, id int64) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM ingestion_jobs WHERE workspace_id = $1 AND id = $2`
+
+	job, err := scanJob(r.db.QueryRowContext(ctx, query, workspaceID, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+func (r *PostgresRepository) QueueStats(ctx context.Context, now time.Time) (*models.QueueStats, error) {
+	query := `SELECT
+			COUNT(*) FILTER (WHERE status = $1),
+			COUNT(*) FILTER (WHERE status = $2),
+			MIN(run_at) FILTER (WHERE status = $1 AND run_at <= $3)
+		FROM ingestion_jobs WHERE status IN ($1, $2)`
+
+	var stats models.QueueStats
+	var oldest sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, models.JobStatusQueued, models.JobStatusRunning, now).
+		Scan(&stats.Queued, &stats.Running, &oldest)
+	if err != nil {
+		return nil, err
+	}
+	if oldest.Valid {
+		stats.OldestQueuedAt = &oldest.Time
+	}
+	return &stats, nil
+}
+
+func (r *PostgresRepository) SaveWatchlistEntry(ctx context.Context, entry *models.WatchlistEntry) error {
+	query := `
+		INSERT INTO watchlist (workspace_id, product_id, tags, cities, priority, poll_interval_seconds, own_sku, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (workspace_id, product_id) DO UPDATE SET
+			tags = EXCLUDED.tags,
+			cities = EXCLUDED.cities,
+			priority = EXCLUDED.priority,
+			poll_interval_seconds = EXCLUDED.poll_interval_seconds,
+			own_sku = EXCLUDED.own_sku,
+			notes = EXCLUDED.notes,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		entry.WorkspaceID,
+		entry.ProductID,
+		pq.Array(entry.Tags),
+		pq.Array(entry.Cities),
+		entry.Priority,
+		entry.PollIntervalSeconds,
+		entry.OwnSKU,
+		entry.Notes,
+		entry.UpdatedAt,
+	).Scan(&entry.CreatedAt, &entry.UpdatedAt)
+}
+
+const watchlistColumns = `workspace_id, product_id, tags, cities, priority, poll_interval_seconds, own_sku, notes, created_at, updated_at`
+
+func scanWatchlistEntry(row interface{ Scan(...interface{}) error }) (*models.WatchlistEntry, error) {
+	var entry models.WatchlistEntry
+	err := row.Scan(&entry.WorkspaceID, &entry.ProductID, pq.Array(&entry.Tags), pq.Array(&entry.Cities), &entry.Priority, &entry.PollIntervalSeconds,
+		&entry.OwnSKU, &entry.Notes, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Tags == nil {
+		entry.Tags = []string{}
+	}
+	if entry.Cities == nil {
+		entry.Cities = []string{}
+	}
+	return &entry, nil
+}
+
+func (r *PostgresRepository) GetWatchlist(ctx context.Context, workspaceID string, tags []string) ([]models.WatchlistEntry, error) {
+	// Без тегов возвращаем весь список, с тегами - записи, у которых есть хотя бы один из них
+	query := `
+		SELECT ` + watchlistColumns + `
+		FROM watchlist
+		WHERE workspace_id = $1 AND (cardinality($2::TEXT[]) = 0 OR tags && $2::TEXT[])
+		ORDER BY priority DESC, product_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, pq.Array(tags))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.WatchlistEntry{}
+	for rows.Next() {
+		entry, err := scanWatchlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *PostgresRepository) GetWatchlistEntry(ctx context.Context, workspaceID, productID string) (*models.WatchlistEntry, error) {
+	query := `SELECT ` + watchlistColumns + ` FROM watchlist WHERE workspace_id = $1 AND product_id = $2`
+
+	entry, err := scanWatchlistEntry(r.db.QueryRowContext(ctx, query, workspaceID, productID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return entry, err
+}
+
+func (r *PostgresRepository) DeleteWatchlistEntry(ctx context.Context, workspaceID, productID string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM watchlist WHERE workspace_id = $1 AND product_id = $2`, workspaceID, productID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *PostgresRepository) ExportPriceHistory(ctx context.Context, workspaceID string, filter models.HistoryFilter, fn func(models.PriceHistory) error) error {
+	// Строки отдаются по одной, чтобы выгрузка не держала всю историю в памяти
+	query := `
+		SELECT id, workspace_id, product_id, city_id, seller_id, price, timestamp
+		FROM price_history
+		WHERE workspace_id = $1
+			AND (cardinality($2::TEXT[]) = 0 OR product_id = ANY($2::TEXT[]))
+			AND ($3 = '' OR city_id = $3)
+			AND ($4::TIMESTAMP IS NULL OR timestamp >= $4)
+			AND ($5::TIMESTAMP IS NULL OR timestamp <= $5)
+		ORDER BY product_id, city_id, timestamp, seller_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, workspaceID, pq.Array(filter.ProductIDs), filter.CityID, nullableTime(filter.From), nullableTime(filter.To))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h models.PriceHistory
+		if err := rows.Scan(&h.ID, &h.WorkspaceID, &h.ProductID, &h.CityID, &h.SellerID, &h.Price, &h.Timestamp); err != nil {
+			return err
+		}
+		if err := fn(h); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *PostgresRepository) Purge(ctx context.Context, before time.Time, dryRun bool) (*models.PurgeResult, error) {
+	result := &models.PurgeResult{Before: before, DryRun: dryRun}
+
+	// Незавершенные задачи очереди не трогаем, даже если они старые
+	targets := []struct {
+		table string
+		where string
+		count *int64
+	}{
+		{"price_history", "timestamp < $1", &result.PriceHistory},
+		{"product_info", "timestamp < $1", &result.ProductInfo},
+		{"quarantined_offers", "created_at < $1", &result.QuarantinedOffers},
+		{"ingestion_jobs", "finished_at < $1 AND status IN ('" + models.JobStatusSucceeded + "', '" + models.JobStatusFailed + "')", &result.Jobs},
+		{"rate_limit_buckets", "updated_at < $1", &result.RateLimitBuckets},
+		{"rate_limit_quotas", "day < $1", &result.RateLimitQuotas},
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, target := range targets {
+		if dryRun {
+			err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+target.table+` WHERE `+target.where, before).Scan(target.count)
+		} else {
+			var res sql.Result
+			if res, err = tx.ExecContext(ctx, `DELETE FROM `+target.table+` WHERE `+target.where, before); err == nil {
+				*target.count, err = res.RowsAffected()
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", target.table, err)
+		}
+	}
+
+	return result, tx.Commit()
+}
+
+// nullableJSON превращает пустой JSON в NULL
+func nullableJSON(payload []byte) interface{} {
+	if len(payload) == 0 {
+		return nil
+	}
+	return payload
+}
+
+// nullableTime превращает нулевое время в NULL
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// DB - пул соединений, его статистика отдается в метриках
+func (r *PostgresRepository) DB() *sql.DB {
+	return r.db
+}
+
 func (r *PostgresRepository) HealthCheck(ctx context.Context) error {
 	return r.db.PingContext(ctx)
 }
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"Mini-Quicko/internal/logging"
+)
+
+// Как часто реплика удаляет заполненные корзины и квоты прошедших суток, как MemoryStore
+const rateLimitSweepInterval = time.Minute
+
+// refilledTokens - остаток корзины b к моменту $4 с частотой $2 и запасом $3
+const refilledTokens = `LEAST($3::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM $4::timestamp - b.updated_at)::float8) * $2::float8)`
+
+// TakeToken атомарно пополняет корзину за прошедшее время и забирает из нее токен.
+// Время хранится в UTC, чтобы реплики в разных часовых поясах считали одинаково.
+// full_at - момент, когда корзина снова заполнится: после него строку можно удалить.
+func (r *PostgresRepository) TakeToken(ctx context.Context, key string, rate float64, burst int, now time.Time) (bool, float64, error) {
+	now = now.UTC()
+	r.sweepRateLimits(ctx, now)
+
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, full_at)
+		VALUES ($1, $3::float8 - 1, $4::timestamp, $4::timestamp + make_interval(secs => 1 / $2::float8))
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = ` + refilledTokens + ` - 1,
+			updated_at = $4::timestamp,
+			full_at = $4::timestamp + make_interval(secs => ($3::float8 - ` + refilledTokens + ` + 1) / $2::float8)
+		WHERE ` + refilledTokens + ` >= 1
+		RETURNING tokens
+	`
+
+	var tokens float64
+	err := r.db.QueryRowContext(ctx, query, key, rate, burst, now).Scan(&tokens)
+	if err == nil {
+		return true, tokens, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, 0, err
+	}
+
+	// Токенов нет: строка не изменилась, остаток нужен для Retry-After
+	query = `
+		SELECT ` + refilledTokens + `
+		FROM rate_limit_buckets b
+		WHERE key = $1
+	`
+	if err := r.db.QueryRowContext(ctx, query, key, rate, burst, now).Scan(&tokens); err != nil {
+		return false, 0, err
+	}
+	return false, tokens, nil
+}
+
+// IncrementQuota увеличивает счетчик за сутки, только если лимит еще не исчерпан
+func (r *PostgresRepository) IncrementQuota(ctx context.Context, key string, day time.Time, limit int) (int, bool, error) {
+	query := `
+		INSERT INTO rate_limit_quotas AS q (key, day, count)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (key, day) DO UPDATE SET count = q.count + 1
+		WHERE q.count < $3
+		RETURNING count
+	`
+
+	var used int
+	err := r.db.QueryRowContext(ctx, query, key, day.UTC().Format(time.DateOnly), limit).Scan(&used)
+	if err == sql.ErrNoRows {
+		return limit, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return used, true, nil
+}
+
+// sweepRateLimits не чаще раза в rateLimitSweepInterval удаляет заполненные корзины (они ничем
+// не отличаются от новых) и квоты прошедших суток. Ошибка очистки не мешает проверке запроса.
+func (r *PostgresRepository) sweepRateLimits(ctx context.Context, now time.Time) {
+	sweptAt := r.rateLimitSweptAt.Load()
+	if now.Sub(time.Unix(0, sweptAt)) < rateLimitSweepInterval || !r.rateLimitSweptAt.CompareAndSwap(sweptAt, now.UnixNano()) {
+		return
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE full_at <= $1::timestamp`, now); err != nil {
+		slog.WarnContext(ctx, "failed to expire rate limit buckets", logging.Err(err))
+	}
+	today := now.Truncate(24 * time.Hour).Format(time.DateOnly)
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit_quotas WHERE day < $1`, today); err != nil {
+		slog.WarnContext(ctx, "failed to expire rate limit quotas", logging.Err(err))
+	}
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// CreateWorkspace создает рабочее пространство; false - пространство с таким ID уже есть
+func (r *PostgresRepository) CreateWorkspace(ctx context.Context, workspace *models.Workspace) (bool, error) {
+	query := `
+		INSERT INTO workspaces (id, name, own_merchant_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO NOTHING
+	`
+	res, err := r.db.ExecContext(ctx, query, workspace.ID, workspace.Name, workspace.OwnMerchantID, workspace.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+const workspaceColumns = `id, name, own_merchant_id, created_at`
+
+func scanWorkspace(row interface{ Scan(...interface{}) error }) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := row.Scan(&workspace.ID, &workspace.Name, &workspace.OwnMerchantID, &workspace.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (r *PostgresRepository) GetWorkspace(ctx context.Context, id string) (*models.Workspace, error) {
+	query := `SELECT ` + workspaceColumns + ` FROM workspaces WHERE id = $1`
+
+	workspace, err := scanWorkspace(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return workspace, err
+}
+
+func (r *PostgresRepository) ListWorkspaces(ctx context.Context) ([]models.Workspace, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+workspaceColumns+` FROM workspaces ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		workspace, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, *workspace)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// UpdateWorkspace меняет название и нашего продавца; false - пространства нет
+func (r *PostgresRepository) UpdateWorkspace(ctx context.Context, workspace *models.Workspace) (bool, error) {
+	query := `
+		UPDATE workspaces SET name = $2, own_merchant_id = $3
+		WHERE id = $1
+		RETURNING created_at
+	`
+	err := r.db.QueryRowContext(ctx, query, workspace.ID, workspace.Name, workspace.OwnMerchantID).Scan(&workspace.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
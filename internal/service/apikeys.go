@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"Mini-Quicko/internal/auth"
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/logging"
+)
+
+// Время последнего использования ключа обновляется не чаще этого интервала,
+// чтобы не писать в БД на каждый запрос
+const apiKeyTouchInterval = time.Minute
+
+func (s *service) CreateAPIKey(ctx context.Context, workspaceID string, request *models.CreateAPIKeyRequest) (*models.NewAPIKey, error) {
+	if _, err := s.GetWorkspace(ctx, workspaceID); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, errs.Validation("key name is required")
+	}
+
+	roles, err := normalizeRoles(request.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	key, prefix, err := auth.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	created := &models.NewAPIKey{
+		APIKey: models.APIKey{
+			WorkspaceID: workspaceID,
+			Name:        name,
+			Prefix:      prefix,
+			Hash:        auth.HashKey(key),
+			Roles:       roles,
+			CreatedAt:   time.Now(),
+		},
+		Key: key,
+	}
+	if err := s.repo.CreateAPIKey(ctx, &created.APIKey); err != nil {
+		return nil, storageError(err, "failed to create API key")
+	}
+
+	return created, nil
+}
+
+func (s *service) ListAPIKeys(ctx context.Context, workspaceID string) ([]models.APIKey, error) {
+	keys, err := s.repo.ListAPIKeys(ctx, workspaceID)
+	if err != nil {
+		return nil, storageError(err, "failed to list API keys")
+	}
+	return keys, nil
+}
+
+func (s *service) RevokeAPIKey(ctx context.Context, workspaceID string, id int64) error {
+	revoked, err := s.repo.RevokeAPIKey(ctx, workspaceID, id, time.Now())
+	if err != nil {
+		return storageError(err, "failed to revoke API key")
+	}
+
+	if !revoked {
+		return errs.NotFound("active API key %d not found", id)
+	}
+
+	return nil
+}
+
+// Authenticate находит действующий ключ. Причина отказа клиенту не сообщается.
+func (s *service) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if !auth.WellFormed(key) {
+		return nil, errs.Unauthorized("invalid API key")
+	}
+
+	apiKey, err := s.repo.GetAPIKeyByHash(ctx, auth.HashKey(key))
+	if err != nil {
+		return nil, storageError(err, "failed to get API key")
+	}
+
+	if apiKey == nil || apiKey.RevokedAt != nil {
+		return nil, errs.Unauthorized("invalid API key")
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.repo.TouchAPIKey(ctx, apiKey.ID, now); err != nil {
+			slog.WarnContext(ctx, "failed to update last use of API key", "api_key_id", apiKey.ID, logging.Err(err))
+		}
+		apiKey.LastUsedAt = &now
+	}
+
+	return apiKey, nil
+}
+
+// normalizeRoles проверяет роли, убирает повторы и упорядочивает их
+func normalizeRoles(roles []models.Role) ([]models.Role, error) {
+	if len(roles) == 0 {
+		return nil, errs.Validation("at least one role is required")
+	}
+
+	wanted := make(map[models.Role]bool, len(roles))
+	for _, role := range roles {
+		role = models.Role(strings.ToLower(strings.TrimSpace(string(role))))
+		if !isKnownRole(role) {
+			return nil, errs.Validation("unknown role %q", role)
+		}
+		wanted[role] = true
+	}
+
+	normalized := make([]models.Role, 0, len(wanted))
+	for _, role := range models.Roles {
+		if wanted[role] {
+			normalized = append(normalized, role)
+		}
+	}
+	return normalized, nil
+}
+
+func isKnownRole(role models.Role) bool {
+	for _, known := range models.Roles {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+)
+
+// pricingStrategy возвращает цену, которую мы бы выставили при данном наборе конкурентов
+type pricingStrategy func(s *service, productID string, competitors []models.Seller) float64
+
+var pricingStrategies = map[string]pricingStrategy{
+	// Текущая формула calculateOptimalPrice
+	"current": func(s *service, productID string, competitors []models.Seller) float64 {
+		return s.analyzePrices(productID, competitors).OptimalPrice
+	},
+	// Совпадаем с минимальной ценой конкурентов
+	"match_min": func(s *service, productID string, competitors []models.Seller) float64 {
+		return minSellerPrice(competitors)
+	},
+	// Опускаемся на 1% ниже минимальной цены, округляя до 10 тенге
+	"undercut": func(s *service, productID string, competitors []models.Seller) float64 {
+		return math.Floor(minSellerPrice(competitors)*0.99/10) * 10
+	},
+	// Средняя цена конкурентов, округленная до 1000
+	"average": func(s *service, productID string, competitors []models.Seller) float64 {
+		total := 0.0
+		for _, seller := range competitors {
+			total += seller.Price
+		}
+		return math.Round(total/float64(len(competitors))/1000) * 1000
+	},
+	// Медианная цена конкурентов, округленная до 1000
+	"median": func(s *service, productID string, competitors []models.Seller) float64 {
+		return math.Round(s.analyzePrices(productID, competitors).MedianPrice/1000) * 1000
+	},
+	// Текущая формула, но со средней ценой, взвешенной по репутации продавцов
+	"reputation_weighted": func(s *service, productID string, competitors []models.Seller) float64 {
+		analysis := s.analyzePrices(productID, competitors)
+		return s.calculateOptimalPrice(analysis.MinPrice, analysis.WeightedAvgPrice, competitors, nil)
+	},
+}
+
+// PricingStrategies возвращает имена доступных стратегий в алфавитном порядке
+func PricingStrategies() []string {
+	names := make([]string, 0, len(pricingStrategies))
+	for name := range pricingStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *service) Backtest(ctx context.Context, workspaceID string, request *models.BacktestRequest) (*models.BacktestReport, error) {
+	strategies := request.Strategies
+	if len(strategies) == 0 {
+		strategies = PricingStrategies()
+	}
+	for _, name := range strategies {
+		if _, ok := pricingStrategies[name]; !ok {
+			return nil, errs.Validation("unknown pricing strategy %q", name)
+		}
+	}
+
+	to := request.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	cityID := s.cityID(request.CityID)
+	snapshots, err := s.repo.GetProductSnapshots(ctx, workspaceID, request.ProductID, cityID, request.From, to)
+	if err != nil {
+		return nil, storageError(err, "failed to get product snapshots")
+	}
+
+	if len(snapshots) == 0 {
+		return nil, errs.NotFound("no data found for product %s in city %s", request.ProductID, cityID)
+	}
+
+	report := &models.BacktestReport{
+		ProductID: request.ProductID,
+		CityID:    cityID,
+		Snapshots: len(snapshots),
+		From:      snapshots[0].Timestamp,
+		To:        snapshots[len(snapshots)-1].Timestamp,
+		Results:   make([]models.StrategyResult, 0, len(strategies)),
+	}
+
+	for _, name := range strategies {
+		report.Results = append(report.Results, s.replayStrategy(name, request, snapshots))
+	}
+
+	return report, nil
+}
+
+// replayStrategy прогоняет снапшоты в хронологическом порядке через стратегию
+func (s *service) replayStrategy(name string, request *models.BacktestRequest, snapshots []models.ProductInfo) models.StrategyResult {
+	strategy := pricingStrategies[name]
+	result := models.StrategyResult{Strategy: name}
+
+	var sumPrice, sumPremium, sumMargin float64
+	var cheapest int
+	prevPrice := 0.0
+
+	for _, snapshot := range snapshots {
+		// Наши собственные офферы не считаются конкурентами
+		competitors := make([]models.Seller, 0, len(snapshot.Sellers))
+		for _, seller := range snapshot.Sellers {
+			if request.MerchantID != "" && seller.ID == request.MerchantID {
+				continue
+			}
+			competitors = append(competitors, seller)
+		}
+		if len(competitors) == 0 {
+			continue
+		}
+
+		minPrice := minSellerPrice(competitors)
+		if minPrice <= 0 {
+			continue
+		}
+		price := strategy(s, request.ProductID, competitors)
+		if price <= 0 {
+			continue
+		}
+
+		result.EvaluatedSnapshots++
+		sumPrice += price
+		sumPremium += (price - minPrice) / minPrice * 100
+		if request.UnitCost > 0 {
+			sumMargin += (price - request.UnitCost) / price * 100
+		}
+		if price <= minPrice {
+			cheapest++
+		}
+		if prevPrice != 0 && price != prevPrice {
+			result.PriceChanges++
+		}
+		prevPrice = price
+	}
+
+	if result.EvaluatedSnapshots > 0 {
+		n := float64(result.EvaluatedSnapshots)
+		result.CheapestRate = float64(cheapest) / n
+		result.AvgPrice = sumPrice / n
+		result.AvgPremiumPercent = sumPremium / n
+		if request.UnitCost > 0 {
+			result.AvgMarginPercent = sumMargin / n
+		}
+	}
+
+	return result
+}
+
+func minSellerPrice(sellers []models.Seller) float64 {
+	minPrice := sellers[0].Price
+	for _, seller := range sellers[1:] {
+		if seller.Price < minPrice {
+			minPrice = seller.Price
+		}
+	}
+	return minPrice
+}
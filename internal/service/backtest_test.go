@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+)
+
+func newTestService(repo ports.Repository) *service {
+	return NewService(repo, Settings{}).(*service)
+}
+
+// strongCheapSellers - сильный продавец с низкой ценой и слабый с высокой:
+// средняя, взвешенная по силе, заметно ниже простой средней
+func strongCheapSellers() []models.Seller {
+	return []models.Seller{
+		{ID: "strong", Price: 100000, Rating: 5, Reviews: 2000, Purchases: 5000, Segment: 3},
+		{ID: "weak", Price: 200000, Rating: 4, Reviews: 3, Purchases: 2, Segment: 1},
+	}
+}
+
+func TestPricingStrategiesDiffer(t *testing.T) {
+	s := newTestService(nil)
+	sellers := strongCheapSellers()
+
+	current := pricingStrategies["current"](s, "p1", sellers)
+	weighted := pricingStrategies["reputation_weighted"](s, "p1", sellers)
+
+	// current воспроизводит формулу сервиса: середина между минимальной и простой средней
+	if want := s.calculateOptimalPrice(100000, 150000, sellers, nil); current != want {
+		t.Errorf("current = %v, want %v", current, want)
+	}
+	if analysis := s.analyzePrices("p1", sellers); analysis.OptimalPrice != current {
+		t.Errorf("analysis optimal price %v, current strategy %v", analysis.OptimalPrice, current)
+	}
+	if weighted >= current {
+		t.Errorf("reputation_weighted = %v, want below current %v", weighted, current)
+	}
+}
+
+func TestReplayStrategy(t *testing.T) {
+	snapshots := []models.ProductInfo{
+		{Sellers: []models.Seller{{ID: "own", Price: 95000}, {ID: "a", Price: 100000}, {ID: "b", Price: 120000}}},
+		{Sellers: []models.Seller{{ID: "a", Price: 90000}, {ID: "b", Price: 120000}}},
+		{Sellers: []models.Seller{{ID: "own", Price: 95000}}},
+		{Sellers: []models.Seller{{ID: "a", Price: 90000}}},
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		request  models.BacktestRequest
+		want     models.StrategyResult
+	}{
+		{
+			name:     "own offers are not competitors",
+			strategy: "match_min",
+			request:  models.BacktestRequest{MerchantID: "own", UnitCost: 80000},
+			want: models.StrategyResult{
+				Strategy: "match_min", CheapestRate: 1, AvgPrice: 280000.0 / 3, AvgPremiumPercent: 0,
+				AvgMarginPercent: (20.0 + 100.0/9 + 100.0/9) / 3, PriceChanges: 1, EvaluatedSnapshots: 3,
+			},
+		},
+		{
+			name:     "without merchant every offer competes",
+			strategy: "match_min",
+			request:  models.BacktestRequest{},
+			want: models.StrategyResult{
+				Strategy: "match_min", CheapestRate: 1, AvgPrice: 92500, PriceChanges: 3, EvaluatedSnapshots: 4,
+			},
+		},
+		{
+			name:     "undercut is never above the minimum",
+			strategy: "undercut",
+			request:  models.BacktestRequest{MerchantID: "own"},
+			want: models.StrategyResult{
+				Strategy: "undercut", CheapestRate: 1, AvgPrice: (99000 + 89100 + 89100) / 3.0, AvgPremiumPercent: -1,
+				PriceChanges: 1, EvaluatedSnapshots: 3,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestService(nil).replayStrategy(tt.strategy, &tt.request, snapshots)
+			if !resultsClose(got, tt.want) {
+				t.Errorf("result\ngot  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// resultsClose сравнивает результаты стратегий с допуском на ошибки округления
+func resultsClose(a, b models.StrategyResult) bool {
+	close := func(x, y float64) bool { return math.Abs(x-y) < 1e-6 }
+	return a.Strategy == b.Strategy && a.PriceChanges == b.PriceChanges && a.EvaluatedSnapshots == b.EvaluatedSnapshots &&
+		close(a.CheapestRate, b.CheapestRate) && close(a.AvgPrice, b.AvgPrice) &&
+		close(a.AvgPremiumPercent, b.AvgPremiumPercent) && close(a.AvgMarginPercent, b.AvgMarginPercent)
+}
+
+func TestBacktest(t *testing.T) {
+	day := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	repo := &snapshotRepository{}
+	for _, hour := range []int{9, 21} {
+		repo.snapshots = append(repo.snapshots, models.ProductInfo{
+			WorkspaceID: "w1", ProductID: "p1", CityID: models.DefaultCityID,
+			Sellers:   strongCheapSellers(),
+			Timestamp: day.Add(time.Duration(hour) * time.Hour),
+		})
+	}
+	s := newTestService(repo)
+
+	// Период до конца 31 мая, как его задает дата to=2024-05-31, включает вечерний снапшот
+	report, err := s.Backtest(context.Background(), "w1", &models.BacktestRequest{
+		ProductID: "p1",
+		From:      day,
+		To:        day.AddDate(0, 0, 1).Add(-time.Microsecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Snapshots != 2 || !report.To.Equal(day.Add(21*time.Hour)) {
+		t.Errorf("report covers %d snapshots up to %v, want 2 up to 21:00", report.Snapshots, report.To)
+	}
+	if len(report.Results) != len(pricingStrategies) {
+		t.Errorf("%d results, want every strategy", len(report.Results))
+	}
+
+	tests := []struct {
+		name    string
+		request models.BacktestRequest
+		want    errs.Kind
+	}{
+		{name: "unknown strategy", request: models.BacktestRequest{ProductID: "p1", Strategies: []string{"max"}}, want: errs.KindValidation},
+		{name: "no snapshots in range", request: models.BacktestRequest{ProductID: "p1", To: day}, want: errs.KindNotFound},
+		{name: "other workspace's product", request: models.BacktestRequest{ProductID: "p2"}, want: errs.KindNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := s.Backtest(context.Background(), "w1", &tt.request); errs.KindOf(err) != tt.want {
+			t.Errorf("%s: error %v, want kind %s", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/logging"
+)
+
+// Максимальное число товаров в одном пакетном запросе
+const maxBulkProducts = 1000
+
+func (s *service) AnalyzeProducts(ctx context.Context, workspaceID string, request *models.BulkAnalysisRequest) (*models.BulkAnalysisResult, error) {
+	productIDs := uniqueStrings(request.ProductIDs)
+	cityID := s.cityID(request.CityID)
+
+	// Фильтр дополняет явный список товаров
+	if request.Filter != nil {
+		filter := *request.Filter
+		if filter.Limit <= 0 || filter.Limit > maxBulkProducts {
+			filter.Limit = maxBulkProducts
+		}
+		var filtered []string
+		if filter.Watchlist || len(filter.Tags) > 0 {
+			// Товары из списка отслеживания, при заданных тегах - только с этими тегами
+			entries, err := s.GetWatchlist(ctx, workspaceID, filter.Tags)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				filtered = append(filtered, entry.ProductID)
+			}
+			// Лимит применяется так же, как при выборке из БД: список упорядочен по приоритету
+			if len(filtered) > filter.Limit {
+				filtered = filtered[:filter.Limit]
+			}
+		} else {
+			var err error
+			if filtered, err = s.repo.ListProductIDs(ctx, workspaceID, cityID, filter); err != nil {
+				return nil, storageError(err, "failed to list products")
+			}
+		}
+		productIDs = uniqueStrings(append(productIDs, filtered...))
+	}
+
+	// Записи списка отслеживания дают наш SKU и теги товара
+	watchlist := make(map[string]models.WatchlistEntry)
+	if entries, err := s.repo.GetWatchlist(ctx, workspaceID, nil); err == nil {
+		for _, entry := range entries {
+			watchlist[entry.ProductID] = entry
+		}
+	} else {
+		slog.WarnContext(ctx, "failed to load watchlist for bulk analysis", "workspace_id", workspaceID, logging.Err(err))
+	}
+
+	if len(productIDs) > maxBulkProducts {
+		return nil, errs.Validation("too many products: %d, maximum is %d", len(productIDs), maxBulkProducts)
+	}
+
+	merchantID := request.MerchantID
+	if merchantID == "" {
+		merchantID = s.ownMerchantID(ctx, workspaceID)
+	}
+
+	results := make([]models.ProductAnalysisResult, len(productIDs))
+
+	// Ограниченный пул воркеров, чтобы не перегружать БД
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.settings.BulkWorkers, len(productIDs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.analyzePortfolioProduct(ctx, workspaceID, productIDs[i], cityID, merchantID, watchlist[productIDs[i]])
+			}
+		}()
+	}
+
+	for i := range productIDs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	summary := models.PortfolioSummary{
+		MerchantID: merchantID,
+		Products:   len(results),
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			summary.Failed++
+			continue
+		}
+		summary.Analyzed++
+		if result.Present {
+			summary.Present++
+		}
+		if result.Leading {
+			summary.Leading++
+		}
+		if len(result.Analysis.DumpingSellers) > 0 {
+			summary.WithDumping++
+		}
+		summary.RevenueAtRisk += result.RevenueAtRisk
+	}
+
+	return &models.BulkAnalysisResult{
+		Results: results,
+		Summary: summary,
+	}, nil
+}
+
+// analyzePortfolioProduct анализирует товар и оценивает нашу позицию среди конкурентов.
+// Наш оффер ищется по merchantID, а если товар в списке отслеживания - и по нашему SKU.
+// Выручка под угрозой - наша цена, умноженная на число наших покупок, если мы не самые дешевые
+// или ниже нас есть демпингующие продавцы.
+func (s *service) analyzePortfolioProduct(ctx context.Context, workspaceID, productID, cityID, merchantID string, entry models.WatchlistEntry) models.ProductAnalysisResult {
+	result := models.ProductAnalysisResult{
+		ProductID: productID,
+		OwnSKU:    entry.OwnSKU,
+		Tags:      entry.Tags,
+	}
+
+	analysis, err := s.AnalyzeProduct(ctx, workspaceID, productID, cityID)
+	if err != nil {
+		result.Error = errs.Message(err)
+		if !errs.Is(err, errs.KindNotFound) {
+			slog.WarnContext(ctx, "failed to analyze product", "workspace_id", workspaceID, "product_id", productID, logging.Err(err))
+		}
+		return result
+	}
+	result.Analysis = analysis
+
+	if merchantID == "" && entry.OwnSKU == "" {
+		return result
+	}
+
+	var own *models.Seller
+	minCompetitorPrice := 0.0
+	for i, seller := range analysis.Sellers {
+		if (merchantID != "" && seller.ID == merchantID) || (entry.OwnSKU != "" && seller.SKU == entry.OwnSKU) {
+			own = &analysis.Sellers[i]
+			continue
+		}
+		if minCompetitorPrice == 0 || seller.Price < minCompetitorPrice {
+			minCompetitorPrice = seller.Price
+		}
+	}
+	if own == nil {
+		return result
+	}
+
+	result.Present = true
+	result.Leading = minCompetitorPrice == 0 || own.Price <= minCompetitorPrice
+
+	dumpingBelow := false
+	for _, seller := range analysis.DumpingSellers {
+		if seller.ID != own.ID && seller.Price < own.Price {
+			dumpingBelow = true
+			break
+		}
+	}
+	if !result.Leading || dumpingBelow {
+		result.RevenueAtRisk = own.Price * float64(own.Purchases)
+	}
+
+	return result
+}
+
+// uniqueStrings убирает пустые и повторяющиеся значения, сохраняя порядок
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
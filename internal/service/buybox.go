@@ -0,0 +1,302 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// Модель первого места в выдаче Kaspi: условный логит (softmax) по офферам одного снапшота.
+// P(оффер i первый) = exp(w·x_i) / Σ exp(w·x_j), веса w обучаются по сохраненному порядку офферов.
+
+const (
+	buyBoxEpochs            = 200
+	buyBoxLearningRate      = 0.1
+	buyBoxRegularization    = 0.05
+	buyBoxTrainingWindow    = 30 // дней истории для обучения
+	buyBoxMinSnapshotOffers = 2
+
+	// Обученные веса переиспользуются: обучение - 200 эпох по снапшотам за 30 дней, а анализ
+	// вызывается на каждую загрузку, в пакетном анализе и в воркерах очереди
+	buyBoxModelTTL      = time.Hour
+	buyBoxSweepInterval = 10 * time.Minute
+)
+
+var buyBoxFactors = []string{"relative_price", "rating", "reviews", "delivery_days", "kaspi_delivery"}
+
+// Априорные веса: используются без истории и как центр регуляризации при обучении
+var buyBoxPrior = []float64{-8.0, 1.0, 0.5, -0.5, 0.3}
+
+var deliveryDaysPattern = regexp.MustCompile(`\d+`)
+
+type buyBoxModel struct {
+	weights           []float64
+	trainingSnapshots int
+}
+
+type buyBoxKey struct {
+	workspaceID string
+	productID   string
+	cityID      string
+}
+
+type cachedBuyBoxModel struct {
+	model     *buyBoxModel
+	expiresAt time.Time
+}
+
+// buyBoxCache хранит обученные модели по товару и городу до истечения buyBoxModelTTL
+type buyBoxCache struct {
+	mu      sync.Mutex
+	models  map[buyBoxKey]cachedBuyBoxModel
+	sweptAt time.Time
+}
+
+func newBuyBoxCache() *buyBoxCache {
+	return &buyBoxCache{models: make(map[buyBoxKey]cachedBuyBoxModel)}
+}
+
+// get возвращает действующую модель или nil
+func (c *buyBoxCache) get(key buyBoxKey, now time.Time) *buyBoxModel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.models[key]
+	if !ok || now.After(cached.expiresAt) {
+		return nil
+	}
+	return cached.model
+}
+
+func (c *buyBoxCache) put(key buyBoxKey, model *buyBoxModel, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(now)
+	c.models[key] = cachedBuyBoxModel{model: model, expiresAt: now.Add(buyBoxModelTTL)}
+}
+
+// invalidate удаляет модель, обученную до появления новых данных товара
+func (c *buyBoxCache) invalidate(key buyBoxKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.models, key)
+}
+
+// sweep удаляет устаревшие модели, чтобы кэш не рос с числом когда-либо проанализированных товаров
+func (c *buyBoxCache) sweep(now time.Time) {
+	if now.Sub(c.sweptAt) < buyBoxSweepInterval {
+		return
+	}
+	c.sweptAt = now
+
+	for key, cached := range c.models {
+		if now.After(cached.expiresAt) {
+			delete(c.models, key)
+		}
+	}
+}
+
+// buyBoxFeatures строит признаки оффера относительно минимальной цены снапшота
+func buyBoxFeatures(seller models.Seller, minPrice float64) []float64 {
+	relativePrice := 0.0
+	if minPrice > 0 {
+		relativePrice = (seller.Price - minPrice) / minPrice
+	}
+	kaspiDelivery := 0.0
+	if seller.KaspiDelivery {
+		kaspiDelivery = 1
+	}
+
+	return []float64{
+		relativePrice,
+		seller.Rating - 4.0,
+		math.Log1p(float64(seller.Reviews)) / 10,
+		deliveryDays(seller.DeliveryDuration) / 5,
+		kaspiDelivery,
+	}
+}
+
+// deliveryDays переводит deliveryDuration Kaspi (TODAY, TOMORROW, TILL_2_DAYS, ...) в дни
+func deliveryDays(duration string) float64 {
+	switch duration {
+	case "TODAY":
+		return 0
+	case "TOMORROW":
+		return 1
+	case "":
+		return 3
+	}
+	if match := deliveryDaysPattern.FindString(duration); match != "" {
+		if days, err := strconv.Atoi(match); err == nil {
+			return float64(days)
+		}
+	}
+	return 3
+}
+
+// trainBuyBoxModel обучает веса градиентным подъемом по снапшотам, где сохранена позиция офферов
+func trainBuyBoxModel(snapshots []models.ProductInfo) *buyBoxModel {
+	model := &buyBoxModel{weights: append([]float64(nil), buyBoxPrior...)}
+
+	type example struct {
+		features [][]float64
+		first    int
+	}
+
+	var examples []example
+	for _, snapshot := range snapshots {
+		if len(snapshot.Sellers) < buyBoxMinSnapshotOffers {
+			continue
+		}
+
+		minPrice := minSellerPrice(snapshot.Sellers)
+		first := -1
+		features := make([][]float64, len(snapshot.Sellers))
+		for i, seller := range snapshot.Sellers {
+			features[i] = buyBoxFeatures(seller, minPrice)
+			if seller.Position > 0 && (first < 0 || seller.Position < snapshot.Sellers[first].Position) {
+				first = i
+			}
+		}
+		// Старые снапшоты без позиций не годятся для обучения
+		if first < 0 {
+			continue
+		}
+		examples = append(examples, example{features: features, first: first})
+	}
+
+	model.trainingSnapshots = len(examples)
+	if len(examples) == 0 {
+		return model
+	}
+
+	for epoch := 0; epoch < buyBoxEpochs; epoch++ {
+		gradient := make([]float64, len(model.weights))
+		for _, ex := range examples {
+			probabilities := model.probabilities(ex.features)
+			for i, features := range ex.features {
+				indicator := 0.0
+				if i == ex.first {
+					indicator = 1
+				}
+				for k, value := range features {
+					gradient[k] += (indicator - probabilities[i]) * value
+				}
+			}
+		}
+
+		for k := range model.weights {
+			// L2-регуляризация тянет веса к априорным, чтобы малая история не давала выбросов
+			gradient[k] = gradient[k]/float64(len(examples)) - buyBoxRegularization*(model.weights[k]-buyBoxPrior[k])
+			model.weights[k] += buyBoxLearningRate * gradient[k]
+		}
+	}
+
+	return model
+}
+
+// probabilities возвращает softmax по офферам снапшота
+func (m *buyBoxModel) probabilities(features [][]float64) []float64 {
+	scores := make([]float64, len(features))
+	maxScore := math.Inf(-1)
+	for i, f := range features {
+		for k, value := range f {
+			scores[i] += m.weights[k] * value
+		}
+		maxScore = math.Max(maxScore, scores[i])
+	}
+
+	total := 0.0
+	for i := range scores {
+		scores[i] = math.Exp(scores[i] - maxScore)
+		total += scores[i]
+	}
+	for i := range scores {
+		scores[i] /= total
+	}
+	return scores
+}
+
+func (m *buyBoxModel) sellerProbabilities(sellers []models.Seller) []float64 {
+	minPrice := minSellerPrice(sellers)
+	features := make([][]float64, len(sellers))
+	for i, seller := range sellers {
+		features[i] = buyBoxFeatures(seller, minPrice)
+	}
+	return m.probabilities(features)
+}
+
+// isTop проверяет, что у продавца index наибольшая вероятность первого места
+func (m *buyBoxModel) isTop(sellers []models.Seller, index int) bool {
+	probabilities := m.sellerProbabilities(sellers)
+	for i, p := range probabilities {
+		if i != index && p >= probabilities[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// topSlotPrice ищет бинарным поиском максимальную цену, при которой продавец выходит на первое место.
+// Возвращает 0, если первое место недостижимо даже при цене в два раза ниже минимальной.
+func (m *buyBoxModel) topSlotPrice(sellers []models.Seller, index int) float64 {
+	if m.isTop(sellers, index) {
+		return sellers[index].Price
+	}
+
+	candidate := make([]models.Seller, len(sellers))
+	copy(candidate, sellers)
+
+	low := minSellerPrice(sellers) * 0.5
+	high := sellers[index].Price
+	candidate[index].Price = low
+	if low <= 0 || !m.isTop(candidate, index) {
+		return 0
+	}
+
+	for i := 0; i < 40 && high-low > 1; i++ {
+		mid := (low + high) / 2
+		candidate[index].Price = mid
+		if m.isTop(candidate, index) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	return math.Floor(low)
+}
+
+func (m *buyBoxModel) predict(sellers []models.Seller) *models.BuyBoxPrediction {
+	prediction := &models.BuyBoxPrediction{
+		TrainingSnapshots: m.trainingSnapshots,
+		Factors:           make(map[string]float64, len(buyBoxFactors)),
+		Sellers:           make([]models.BuyBoxEstimate, 0, len(sellers)),
+	}
+	for k, name := range buyBoxFactors {
+		prediction.Factors[name] = m.weights[k]
+	}
+	if len(sellers) == 0 {
+		return prediction
+	}
+
+	probabilities := m.sellerProbabilities(sellers)
+	for i, seller := range sellers {
+		prediction.Sellers = append(prediction.Sellers, models.BuyBoxEstimate{
+			SellerID:     seller.ID,
+			Name:         seller.Name,
+			Price:        seller.Price,
+			Position:     seller.Position,
+			Probability:  probabilities[i],
+			TopSlotPrice: m.topSlotPrice(sellers, i),
+		})
+	}
+
+	return prediction
+}
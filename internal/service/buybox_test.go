@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+)
+
+// snapshotRepository хранит сохраненные снапшоты в памяти; остальные методы репозитория не нужны
+type snapshotRepository struct {
+	ports.Repository
+	snapshots []models.ProductInfo
+}
+
+func (r *snapshotRepository) SaveSnapshot(ctx context.Context, productInfo *models.ProductInfo) error {
+	r.snapshots = append(r.snapshots, *productInfo)
+	return nil
+}
+
+func (r *snapshotRepository) SaveQuarantinedOffers(ctx context.Context, offers []models.QuarantinedOffer) error {
+	return nil
+}
+
+func (r *snapshotRepository) GetProductSnapshots(ctx context.Context, workspaceID, productID, cityID string, from, to time.Time) ([]models.ProductInfo, error) {
+	var snapshots []models.ProductInfo
+	for _, snapshot := range r.snapshots {
+		if snapshot.WorkspaceID == workspaceID && snapshot.ProductID == productID && snapshot.CityID == cityID &&
+			!snapshot.Timestamp.Before(from) && !snapshot.Timestamp.After(to) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+// fastBeatsCheap - выдача, где первым стоит более дорогой продавец с доставкой Kaspi сегодня
+func fastBeatsCheap() []models.Seller {
+	return []models.Seller{
+		{ID: "fast", Price: 120000, Rating: 4.5, Reviews: 100, DeliveryDuration: "TODAY", KaspiDelivery: true, Position: 1},
+		{ID: "cheap", Price: 100000, Rating: 4.5, Reviews: 100, DeliveryDuration: "TILL_5_DAYS", Position: 2},
+	}
+}
+
+func repeatSnapshots(sellers []models.Seller, n int) []models.ProductInfo {
+	snapshots := make([]models.ProductInfo, n)
+	for i := range snapshots {
+		snapshots[i] = models.ProductInfo{Sellers: sellers}
+	}
+	return snapshots
+}
+
+func TestTrainBuyBoxModel(t *testing.T) {
+	sellers := fastBeatsCheap()
+	withoutPositions := []models.Seller{{ID: "a", Price: 1}, {ID: "b", Price: 2}}
+
+	tests := []struct {
+		name          string
+		snapshots     []models.ProductInfo
+		wantSnapshots int
+		wantFastTop   bool
+	}{
+		{name: "no history", wantSnapshots: 0},
+		{name: "snapshots without positions", snapshots: repeatSnapshots(withoutPositions, 5), wantSnapshots: 0},
+		{name: "single offer", snapshots: repeatSnapshots(sellers[:1], 5), wantSnapshots: 0},
+		{name: "converging fit", snapshots: repeatSnapshots(sellers, 50), wantSnapshots: 50, wantFastTop: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := trainBuyBoxModel(tt.snapshots)
+			if model.trainingSnapshots != tt.wantSnapshots {
+				t.Errorf("training snapshots %d, want %d", model.trainingSnapshots, tt.wantSnapshots)
+			}
+			if tt.wantSnapshots == 0 {
+				// Без пригодной истории остаются априорные веса, по которым дешевый продавец первый
+				for k := range buyBoxPrior {
+					if model.weights[k] != buyBoxPrior[k] {
+						t.Errorf("weight %s = %v, want prior %v", buyBoxFactors[k], model.weights[k], buyBoxPrior[k])
+					}
+				}
+			}
+			if got := model.isTop(sellers, 0); got != tt.wantFastTop {
+				t.Errorf("fast seller on top = %v, want %v (probabilities %v)", got, tt.wantFastTop, model.sellerProbabilities(sellers))
+			}
+		})
+	}
+}
+
+func TestTrainBuyBoxModelRegularization(t *testing.T) {
+	model := trainBuyBoxModel(repeatSnapshots(fastBeatsCheap(), 50))
+
+	for k, name := range buyBoxFactors {
+		shift := model.weights[k] - buyBoxPrior[k]
+		switch name {
+		case "rating", "reviews":
+			// Признак одинаков у всех продавцов и не несет сигнала: вес остается априорным
+			if shift != 0 {
+				t.Errorf("weight %s moved by %v without signal", name, shift)
+			}
+		default:
+			// Градиент данных по признакам не больше 1, поэтому L2 удерживает вес в 1/λ от априорного
+			if math.IsNaN(shift) || math.Abs(shift) > 1/buyBoxRegularization {
+				t.Errorf("weight %s moved by %v, want at most %v", name, shift, 1/buyBoxRegularization)
+			}
+		}
+	}
+	if model.weights[4] <= buyBoxPrior[4] {
+		t.Errorf("kaspi_delivery weight %v did not grow from prior %v", model.weights[4], buyBoxPrior[4])
+	}
+}
+
+func TestTopSlotPrice(t *testing.T) {
+	prior := trainBuyBoxModel(nil)
+	sellers := fastBeatsCheap()
+
+	tests := []struct {
+		name    string
+		sellers []models.Seller
+		index   int
+		want    float64
+	}{
+		{name: "already on top", sellers: sellers, index: 1, want: 100000},
+		{name: "needs a lower price", sellers: sellers, index: 0, want: 109999},
+		{
+			name: "unreachable",
+			sellers: []models.Seller{
+				{ID: "top", Price: 100000, Rating: 5, Reviews: 50000, DeliveryDuration: "TODAY", KaspiDelivery: true},
+				{ID: "new", Price: 100000, Rating: 0, Reviews: 0, DeliveryDuration: "TILL_30_DAYS"},
+			},
+			index: 1,
+			want:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prior.topSlotPrice(tt.sellers, tt.index); got != tt.want {
+				t.Errorf("topSlotPrice = %v, want %v", got, tt.want)
+			}
+			if tt.want > 0 {
+				// На найденной цене продавец первый, на 2 тенге дороже - уже нет
+				candidate := append([]models.Seller(nil), tt.sellers...)
+				candidate[tt.index].Price = tt.want
+				if !prior.isTop(candidate, tt.index) {
+					t.Errorf("seller is not on top at %v", tt.want)
+				}
+				candidate[tt.index].Price = tt.want + 2
+				if tt.want < tt.sellers[tt.index].Price && prior.isTop(candidate, tt.index) {
+					t.Errorf("seller is still on top at %v", tt.want+2)
+				}
+			}
+		})
+	}
+}
+
+func TestBuyBoxCache(t *testing.T) {
+	now := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+	key := buyBoxKey{workspaceID: "w1", productID: "p1", cityID: "750000000"}
+	model := &buyBoxModel{trainingSnapshots: 7}
+
+	cache := newBuyBoxCache()
+	cache.put(key, model, now)
+
+	tests := []struct {
+		name string
+		key  buyBoxKey
+		at   time.Time
+		want *buyBoxModel
+	}{
+		{name: "same key", key: key, at: now.Add(buyBoxModelTTL), want: model},
+		{name: "expired", key: key, at: now.Add(buyBoxModelTTL + time.Second)},
+		{name: "other workspace", key: buyBoxKey{workspaceID: "w2", productID: "p1", cityID: "750000000"}, at: now},
+		{name: "other product", key: buyBoxKey{workspaceID: "w1", productID: "p2", cityID: "750000000"}, at: now},
+		{name: "other city", key: buyBoxKey{workspaceID: "w1", productID: "p1", cityID: "710000000"}, at: now},
+	}
+	for _, tt := range tests {
+		if got := cache.get(tt.key, tt.at); got != tt.want {
+			t.Errorf("%s: get = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Очистка при записи удаляет устаревшие модели
+	later := now.Add(buyBoxModelTTL + buyBoxSweepInterval)
+	cache.put(buyBoxKey{workspaceID: "w1", productID: "p2", cityID: "750000000"}, model, later)
+	if _, ok := cache.models[key]; ok {
+		t.Error("expired model was not swept")
+	}
+
+	cache.invalidate(buyBoxKey{workspaceID: "w1", productID: "p2", cityID: "750000000"})
+	if len(cache.models) != 0 {
+		t.Errorf("%d models left after invalidate", len(cache.models))
+	}
+}
+
+func TestSaveKaspiDataRetrainsBuyBoxModel(t *testing.T) {
+	repo := &snapshotRepository{}
+	s := newTestService(repo)
+	key := buyBoxKey{workspaceID: "w1", productID: "121806358", cityID: models.DefaultCityID}
+
+	// Модель из кэша обучена до новой выдачи
+	stale := &buyBoxModel{weights: buyBoxPrior, trainingSnapshots: 0}
+	s.buyBox.put(key, stale, time.Now())
+
+	request := &models.KaspiDataRequest{
+		ProductID: "121806358",
+		Offers: models.ProductOffers{Offers: []models.Offer{
+			{MerchantId: "30358551", MerchantName: "Mechta", Price: 179990, MerchantRating: 4.8, MerchantReviewsQuantity: 1200},
+			{MerchantId: "15503068", MerchantName: "Sulpak", Price: 184990, MerchantRating: 4.6, MerchantReviewsQuantity: 800},
+		}},
+	}
+	analysis, err := s.SaveKaspiData(context.Background(), "w1", request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if analysis.BuyBox.TrainingSnapshots != 1 {
+		t.Errorf("prediction trained on %d snapshots, want the saved one", analysis.BuyBox.TrainingSnapshots)
+	}
+	if model := s.buyBox.get(key, time.Now()); model == stale {
+		t.Error("stale model is still cached after ingestion")
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+)
+
+// CompareCities сравнивает последние снапшоты товара во всех городах, где он сохранялся
+func (s *service) CompareCities(ctx context.Context, workspaceID, productID string) (*models.CityComparison, error) {
+	cities, err := s.repo.ListProductCities(ctx, workspaceID, productID)
+	if err != nil {
+		return nil, storageError(err, "failed to list product cities")
+	}
+
+	if len(cities) == 0 {
+		return nil, errs.NotFound("no data found for product %s", productID)
+	}
+
+	comparison := &models.CityComparison{
+		ProductID: productID,
+		Cities:    make([]models.CityPriceSummary, 0, len(cities)),
+	}
+
+	var cheapest, mostExpensive *models.CityPriceSummary
+	for _, cityID := range cities {
+		productInfo, err := s.repo.GetProductInfo(ctx, workspaceID, productID, cityID)
+		if err != nil {
+			return nil, storageError(err, "failed to get product info for city %s", cityID)
+		}
+		if len(productInfo.Sellers) == 0 {
+			continue
+		}
+
+		analysis := s.analyzePrices(productID, productInfo.Sellers)
+		comparison.Cities = append(comparison.Cities, models.CityPriceSummary{
+			CityID:       cityID,
+			MinPrice:     analysis.MinPrice,
+			MedianPrice:  analysis.MedianPrice,
+			AvgPrice:     analysis.AvgPrice,
+			OptimalPrice: analysis.OptimalPrice,
+			Sellers:      len(productInfo.Sellers),
+			Timestamp:    productInfo.Timestamp,
+		})
+	}
+
+	for i := range comparison.Cities {
+		city := &comparison.Cities[i]
+		if cheapest == nil || city.MinPrice < cheapest.MinPrice {
+			cheapest = city
+		}
+		if mostExpensive == nil || city.MinPrice > mostExpensive.MinPrice {
+			mostExpensive = city
+		}
+	}
+
+	// Разброс минимальных цен между самым дешевым и самым дорогим городом
+	if cheapest != nil {
+		comparison.CheapestCityID = cheapest.CityID
+		comparison.MostExpensiveCityID = mostExpensive.CityID
+		if cheapest.MinPrice > 0 {
+			comparison.CitySpreadPercent = (mostExpensive.MinPrice - cheapest.MinPrice) / cheapest.MinPrice * 100
+		}
+	}
+
+	return comparison, nil
+}
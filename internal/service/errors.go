@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"Mini-Quicko/internal/core/errs"
+)
+
+// storageError оборачивает ошибку репозитория. Обрыв соединения с БД и таймаут становятся
+// errs.Unavailable (клиент может повторить запрос), остальное - внутренней ошибкой.
+func storageError(err error, format string, args ...interface{}) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return errs.Unavailable(err, "Storage is temporarily unavailable")
+	}
+	return fmt.Errorf(format+": %w", append(args, err)...)
+}
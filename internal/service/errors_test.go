@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"Mini-Quicko/internal/core/errs"
+)
+
+func TestStorageError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect: connection refused")}
+
+	tests := []struct {
+		name string
+		err  error
+		want errs.Kind
+	}{
+		{name: "network", err: fmt.Errorf("query: %w", refused), want: errs.KindUnavailable},
+		{name: "bad connection", err: driver.ErrBadConn, want: errs.KindUnavailable},
+		{name: "connection done", err: sql.ErrConnDone, want: errs.KindUnavailable},
+		{name: "deadline", err: context.DeadlineExceeded, want: errs.KindUnavailable},
+		{name: "driver error", err: errors.New("pq: duplicate key value violates unique constraint"), want: errs.KindInternal},
+	}
+	for _, tt := range tests {
+		err := storageError(tt.err, "failed to save product %s", "p1")
+		if got := errs.KindOf(err); got != tt.want {
+			t.Errorf("%s: kind %q, want %q", tt.name, got, tt.want)
+		}
+		// Исходная ошибка доступна для лога, но не для клиента
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: %v does not wrap the repository error", tt.name, err)
+		}
+		if msg := errs.Message(err); strings.Contains(msg, tt.err.Error()) {
+			t.Errorf("%s: client message %q contains the repository error", tt.name, msg)
+		}
+	}
+
+	err := storageError(errors.New("pq: syntax error"), "failed to save product %s", "p1")
+	if got, want := err.Error(), "failed to save product p1: pq: syntax error"; got != want {
+		t.Errorf("internal error text %q, want %q", got, want)
+	}
+}
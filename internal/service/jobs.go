@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+)
+
+func (s *service) EnqueueKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (*models.Job, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		WorkspaceID: workspaceID,
+		ProductID:   request.ProductID,
+		Status:      models.JobStatusQueued,
+		MaxAttempts: s.settings.JobMaxAttempts,
+		Payload:     payload,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		return nil, storageError(err, "failed to enqueue job")
+	}
+
+	return job, nil
+}
+
+func (s *service) GetJob(ctx context.Context, workspaceID string, id int64) (*models.Job, error) {
+	job, err := s.repo.GetJob(ctx, workspaceID, id)
+	if err != nil {
+		return nil, storageError(err, "failed to get job")
+	}
+
+	if job == nil {
+		return nil, errs.NotFound("job %d not found", id)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+)
+
+func (s *service) ExportPriceHistory(ctx context.Context, workspaceID string, filter models.HistoryFilter, fn func(models.PriceHistory) error) error {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return errs.Validation("invalid period: from %s is after to %s", filter.From.Format(time.DateOnly), filter.To.Format(time.DateOnly))
+	}
+	if err := s.repo.ExportPriceHistory(ctx, workspaceID, filter, fn); err != nil {
+		return storageError(err, "failed to export price history")
+	}
+	return nil
+}
+
+// Purge удаляет историю, снапшоты, карантин и завершенные задачи старше before
+func (s *service) Purge(ctx context.Context, before time.Time, dryRun bool) (*models.PurgeResult, error) {
+	if before.IsZero() || before.After(time.Now()) {
+		return nil, errs.Validation("purge cutoff must be in the past")
+	}
+	result, err := s.repo.Purge(ctx, before, dryRun)
+	if err != nil {
+		return nil, storageError(err, "failed to purge")
+	}
+	return result, nil
+}
@@ -2,97 +2,190 @@ package service
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
 	"math"
+	"sort"
+	"sync/atomic"
 	"time"
 
+	"Mini-Quicko/internal/core/errs"
 	"Mini-Quicko/internal/core/models"
 	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/logging"
 )
 
+// Settings - настраиваемые параметры анализа
+type Settings struct {
+	OwnMerchantID  string     // наш продавец на Kaspi, используется в сводке по портфелю
+	BulkWorkers    int        // число параллельных анализов в пакетном режиме
+	JobMaxAttempts int        // число попыток обработки задачи асинхронной загрузки
+	DefaultCityID  string     // город для запросов без явно указанного города
+	Thresholds     Thresholds // пороги анализа, незаданные - по умолчанию
+}
+
 type service struct {
-	repo ports.Repository
+	repo       ports.Repository
+	settings   Settings
+	thresholds atomic.Pointer[Thresholds]
+	buyBox     *buyBoxCache
 }
 
-func NewService(repo ports.Repository) ports.Service {
-	return &service{
-		repo: repo,
+func NewService(repo ports.Repository, settings Settings) ports.Service {
+	if settings.BulkWorkers <= 0 {
+		settings.BulkWorkers = 1
+	}
+	if settings.JobMaxAttempts <= 0 {
+		settings.JobMaxAttempts = 1
+	}
+	if settings.DefaultCityID == "" {
+		settings.DefaultCityID = models.DefaultCityID
+	}
+	s := &service{
+		repo:     repo,
+		settings: settings,
+		buyBox:   newBuyBoxCache(),
 	}
+	s.SetThresholds(settings.Thresholds)
+	return s
 }
 
-func (s *service) SaveKaspiData(ctx context.Context, request *models.KaspiDataRequest) (*models.ProductAnalysis, error) {
-	// Конвертируем офферы в sellers
-	sellers := make([]models.Seller, len(request.Offers.Offers))
-	for i, offer := range request.Offers.Offers {
-		sellers[i] = models.Seller{
-			ID:        offer.MerchantId,
-			Name:      offer.MerchantName,
-			Price:     offer.Price,
-			Rating:    offer.MerchantRating,
-			Reviews:   offer.MerchantReviewsQuantity,
-			Purchases: offer.PurchaseCount,
-			SKU:       offer.MerchantSku,
-			Segment:   offer.MerchantSegmentId,
-		}
+// cityID возвращает город запроса или город по умолчанию
+func (s *service) cityID(cityID string) string {
+	if cityID == "" {
+		return s.settings.DefaultCityID
 	}
+	return cityID
+}
 
-	// Сохраняем информацию о продукте
-	productInfo := &models.ProductInfo{
-		ProductID: request.ProductID,
-		Sellers:   sellers,
-		Timestamp: time.Now(),
+func (s *service) SaveKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (*models.ProductAnalysis, error) {
+	cityID := s.cityID(request.CityID)
+
+	// Отправляем подозрительные офферы в карантин, чтобы они не попали в историю и анализ
+	thresholds := s.thresholds.Load()
+	offers, quarantined := screenOffers(request, thresholds.OutlierFactor)
+	if len(quarantined) > 0 {
+		now := time.Now()
+		for i := range quarantined {
+			quarantined[i].WorkspaceID = workspaceID
+			quarantined[i].CityID = cityID
+			quarantined[i].CreatedAt = now
+		}
+		if err := s.repo.SaveQuarantinedOffers(ctx, quarantined); err != nil {
+			slog.ErrorContext(ctx, "failed to save quarantined offers", "product_id", request.ProductID, "offers", len(quarantined), logging.Err(err))
+		}
 	}
 
-	if err := s.repo.SaveProductInfo(ctx, productInfo); err != nil {
-		log.Printf("Warning: failed to save product info: %v", err)
+	if len(offers) == 0 {
+		return nil, errs.Validation("all %d offers for product %s were quarantined", len(request.Offers.Offers), request.ProductID)
 	}
 
-	// Сохраняем историю цен
-	for _, seller := range sellers {
-		history := &models.PriceHistory{
-			ProductID: request.ProductID,
-			SellerID:  seller.ID,
-			Price:     seller.Price,
-			Timestamp: time.Now(),
-		}
-		if err := s.repo.SavePriceHistory(ctx, history); err != nil {
-			log.Printf("Warning: failed to save price history for seller %s: %v", seller.ID, err)
+	// Конвертируем офферы в sellers с позициями исходной выдачи Kaspi для модели первого места
+	sellers := make([]models.Seller, len(offers))
+	for i, offer := range offers {
+		sellers[i] = models.Seller{
+			ID:               offer.MerchantId,
+			Name:             offer.MerchantName,
+			Price:            offer.Price,
+			Rating:           offer.MerchantRating,
+			Reviews:          offer.MerchantReviewsQuantity,
+			Purchases:        offer.PurchaseCount,
+			SKU:              offer.MerchantSku,
+			Segment:          offer.MerchantSegmentId,
+			Position:         offer.Position,
+			DeliveryType:     offer.DeliveryType,
+			DeliveryDuration: offer.DeliveryDuration,
+			KaspiDelivery:    offer.KaspiDelivery,
 		}
 	}
 
+	// Сохраняем выдачу и историю цен одним снапшотом
+	productInfo := &models.ProductInfo{
+		WorkspaceID: workspaceID,
+		ProductID:   request.ProductID,
+		CityID:      cityID,
+		Sellers:     sellers,
+		Timestamp:   time.Now(),
+	}
+
+	// Без сохраненных данных анализ не воспроизвести: ошибку получает клиент, а воркер повторит задачу
+	if err := s.repo.SaveSnapshot(ctx, productInfo); err != nil {
+		return nil, storageError(err, "failed to save product snapshot")
+	}
+	// Модель первого места обучена без новой выдачи: переобучаем ее, а не ждем истечения buyBoxModelTTL
+	s.buyBox.invalidate(buyBoxKey{workspaceID: workspaceID, productID: request.ProductID, cityID: cityID})
+
 	// Анализируем цены
 	analysis := s.analyzePrices(request.ProductID, sellers)
+	analysis.CityID = cityID
+	analysis.BuyBox = s.predictBuyBox(ctx, workspaceID, request.ProductID, cityID, sellers)
 	analysis.TotalOffers = request.Offers.Total
+	analysis.QuarantinedOffers = quarantined
 	analysis.AnalysisTime = time.Now().Format(time.RFC3339)
 
 	return analysis, nil
 }
 
-func (s *service) AnalyzeProduct(ctx context.Context, productID string) (*models.ProductAnalysis, error) {
+func (s *service) AnalyzeProduct(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductAnalysis, error) {
+	cityID = s.cityID(cityID)
+
 	// Получаем последние данные из БД
-	productInfo, err := s.repo.GetProductInfo(ctx, productID)
+	productInfo, err := s.repo.GetProductInfo(ctx, workspaceID, productID, cityID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product info: %w", err)
+		return nil, storageError(err, "failed to get product info")
 	}
 
 	if len(productInfo.Sellers) == 0 {
-		return nil, fmt.Errorf("no data found for product %s", productID)
+		return nil, errs.NotFound("no data found for product %s in city %s", productID, cityID)
 	}
 
 	// Анализируем цены
 	analysis := s.analyzePrices(productID, productInfo.Sellers)
+	analysis.CityID = cityID
+	analysis.BuyBox = s.predictBuyBox(ctx, workspaceID, productID, cityID, productInfo.Sellers)
 	analysis.AnalysisTime = time.Now().Format(time.RFC3339)
 
 	return analysis, nil
 }
 
+// predictBuyBox оценивает текущих продавцов моделью первого места. Модель обучается на истории
+// продукта и кэшируется на buyBoxModelTTL, так что обычно запрос только считает вероятности.
+func (s *service) predictBuyBox(ctx context.Context, workspaceID, productID, cityID string, sellers []models.Seller) *models.BuyBoxPrediction {
+	now := time.Now()
+	key := buyBoxKey{workspaceID: workspaceID, productID: productID, cityID: cityID}
+	if model := s.buyBox.get(key, now); model != nil {
+		return model.predict(sellers)
+	}
+
+	snapshots, err := s.repo.GetProductSnapshots(ctx, workspaceID, productID, cityID, now.AddDate(0, 0, -buyBoxTrainingWindow), now)
+	if err != nil {
+		// Без истории модель с априорными весами не кэшируем: при следующем запросе попробуем снова
+		slog.WarnContext(ctx, "failed to load snapshots for buy box model", "product_id", productID, "city_id", cityID, logging.Err(err))
+		return trainBuyBoxModel(nil).predict(sellers)
+	}
+
+	model := trainBuyBoxModel(snapshots)
+	s.buyBox.put(key, model, now)
+	return model.predict(sellers)
+}
+
+// segmentStat накапливает статистику цен внутри одного сегмента продавцов
+type segmentStat struct {
+	count  int
+	total  float64
+	min    float64
+	max    float64
+	prices []float64
+}
+
 func (s *service) analyzePrices(productID string, sellers []models.Seller) *models.ProductAnalysis {
 	if len(sellers) == 0 {
 		return &models.ProductAnalysis{
 			ProductID:      productID,
 			DumpingSellers: []models.Seller{},
+			NoiseSellers:   []models.Seller{},
 			Sellers:        []models.Seller{},
+			Competitors:    []models.RankedSeller{},
+			Segments:       []models.SegmentStats{},
 			AnalysisTime:   time.Now().Format(time.RFC3339),
 		}
 	}
@@ -101,13 +194,7 @@ func (s *service) analyzePrices(productID string, sellers []models.Seller) *mode
 	minPrice := sellers[0].Price
 	maxPrice := sellers[0].Price
 	sumPrice := 0.0
-
-	// Собираем статистику по сегментам
-	segmentStats := make(map[float64]struct {
-		count int
-		total float64
-		min   float64
-	})
+	prices := make([]float64, 0, len(sellers))
 
 	for _, seller := range sellers {
 		if seller.Price < minPrice {
@@ -117,52 +204,126 @@ func (s *service) analyzePrices(productID string, sellers []models.Seller) *mode
 			maxPrice = seller.Price
 		}
 		sumPrice += seller.Price
+		prices = append(prices, seller.Price)
+	}
+
+	avgPrice := sumPrice / float64(len(sellers))
+
+	thresholds := s.thresholds.Load()
+
+	// Ранжируем продавцов по силе репутации
+	competitors := rankSellers(sellers)
+	noise := make(map[string]bool)
+	noiseSellers := []models.Seller{}
+	for _, seller := range competitors {
+		if seller.Score < thresholds.NoiseScore {
+			noise[seller.ID] = true
+			noiseSellers = append(noiseSellers, seller.Seller)
+		}
+	}
 
-		// Статистика по сегментам
+	// Собираем статистику по сегментам без шума: цены продавцов с низкой репутацией
+	// не должны сдвигать пороги демпинга для остальных
+	segmentStats := make(map[float64]segmentStat)
+	for _, seller := range sellers {
+		if noise[seller.ID] {
+			continue
+		}
 		stat := segmentStats[seller.Segment]
 		stat.count++
 		stat.total += seller.Price
 		if stat.count == 1 || seller.Price < stat.min {
 			stat.min = seller.Price
 		}
+		if stat.count == 1 || seller.Price > stat.max {
+			stat.max = seller.Price
+		}
+		stat.prices = append(stat.prices, seller.Price)
 		segmentStats[seller.Segment] = stat
 	}
 
-	avgPrice := sumPrice / float64(len(sellers))
-
 	// Определяем демпингующих продавцов
 	var dumpingSellers []models.Seller
+	dumpingBySegment := make(map[float64]int)
 	for _, seller := range sellers {
+		// Продавцы с низкой репутацией - шум, их цены не считаем демпингом
+		if noise[seller.ID] {
+			continue
+		}
 		// Проверяем демпинг относительно сегмента
 		if stat, exists := segmentStats[seller.Segment]; exists && stat.count > 1 {
 			segmentAvg := stat.total / float64(stat.count)
-			// Демпинг если цена ниже 90% от средней по сегменту или ниже минимальной по сегменту
-			if seller.Price < segmentAvg*0.9 || seller.Price < stat.min*1.05 {
+			// Демпинг если цена ниже доли DumpingRatio от средней по сегменту или ниже минимальной по сегменту
+			if seller.Price < segmentAvg*thresholds.DumpingRatio || seller.Price < stat.min*1.05 {
 				dumpingSellers = append(dumpingSellers, seller)
+				dumpingBySegment[seller.Segment]++
 			}
 		}
 	}
 
-	// Вычисляем оптимальную цену
+	// Вычисляем оптимальную цену; средняя, взвешенная по силе продавцов, используется
+	// только стратегией reputation_weighted в бэктесте
 	optimalPrice := s.calculateOptimalPrice(minPrice, avgPrice, sellers, segmentStats)
+	weightedAvg := weightedAvgPrice(competitors)
+	if weightedAvg == 0 {
+		weightedAvg = avgPrice
+	}
+
+	// Робастная статистика: медиана и перцентили не так чувствительны к выбросам, как среднее
+	sorted := sortedPrices(prices)
+	p25 := percentile(sorted, 25)
+	p75 := percentile(sorted, 75)
+
+	spreadPercent := 0.0
+	if minPrice > 0 {
+		spreadPercent = (maxPrice - minPrice) / minPrice * 100
+	}
 
 	return &models.ProductAnalysis{
-		ProductID:      productID,
-		MinPrice:       minPrice,
-		AvgPrice:       avgPrice,
-		OptimalPrice:   optimalPrice,
-		DumpingSellers: dumpingSellers,
-		Sellers:        sellers,
-		AnalysisTime:   time.Now().Format(time.RFC3339),
+		ProductID:          productID,
+		MinPrice:           minPrice,
+		MaxPrice:           maxPrice,
+		AvgPrice:           avgPrice,
+		WeightedAvgPrice:   weightedAvg,
+		MedianPrice:        percentile(sorted, 50),
+		P10Price:           percentile(sorted, 10),
+		P25Price:           p25,
+		P75Price:           p75,
+		P90Price:           percentile(sorted, 90),
+		StdDev:             stdDev(prices, avgPrice),
+		IQR:                p75 - p25,
+		PriceSpreadPercent: spreadPercent,
+		OptimalPrice:       optimalPrice,
+		DumpingSellers:     dumpingSellers,
+		NoiseSellers:       noiseSellers,
+		Sellers:            sellers,
+		Competitors:        competitors,
+		Segments:           buildSegmentStats(segmentStats, dumpingBySegment),
+		AnalysisTime:       time.Now().Format(time.RFC3339),
 	}
 }
 
-func (s *service) calculateOptimalPrice(minPrice, avgPrice float64, sellers []models.Seller, segmentStats map[float64]struct {
-	count int
-	total float64
-	min   float64
-},
-) float64 {
+// buildSegmentStats превращает накопленную статистику в отсортированный по сегменту список
+func buildSegmentStats(segmentStats map[float64]segmentStat, dumpingBySegment map[float64]int) []models.SegmentStats {
+	segments := make([]models.SegmentStats, 0, len(segmentStats))
+	for segment, stat := range segmentStats {
+		segments = append(segments, models.SegmentStats{
+			Segment:      segment,
+			Count:        stat.count,
+			MinPrice:     stat.min,
+			MaxPrice:     stat.max,
+			AvgPrice:     stat.total / float64(stat.count),
+			MedianPrice:  percentile(sortedPrices(stat.prices), 50),
+			DumpingCount: dumpingBySegment[segment],
+		})
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Segment < segments[j].Segment
+	})
+	return segments
+}
+
+func (s *service) calculateOptimalPrice(minPrice, avgPrice float64, sellers []models.Seller, segmentStats map[float64]segmentStat) float64 {
 	// Логика расчета оптимальной цены:
 	// 1. Берем среднюю цену между минимальной и средней
 	// 2. Учитываем сегмент продавца
@@ -186,14 +347,40 @@ func (s *service) calculateOptimalPrice(minPrice, avgPrice float64, sellers []mo
 	return math.Round(optimal/1000) * 1000
 }
 
-func (s *service) GetPriceHistory(ctx context.Context, productID string) ([]models.PriceHistory, error) {
-	return s.repo.GetPriceHistory(ctx, productID, 100) // последние 100 записей
+func (s *service) GetPriceHistory(ctx context.Context, workspaceID, productID, cityID string) ([]models.PriceHistory, error) {
+	history, err := s.repo.GetPriceHistory(ctx, workspaceID, productID, s.cityID(cityID), 100) // последние 100 записей
+	if err != nil {
+		return nil, storageError(err, "failed to get price history")
+	}
+	return history, nil
+}
+
+func (s *service) GetProductInfo(ctx context.Context, workspaceID, productID, cityID string) (*models.ProductInfo, error) {
+	cityID = s.cityID(cityID)
+
+	productInfo, err := s.repo.GetProductInfo(ctx, workspaceID, productID, cityID)
+	if err != nil {
+		return nil, storageError(err, "failed to get product info")
+	}
+
+	if len(productInfo.Sellers) == 0 {
+		return nil, errs.NotFound("no data found for product %s in city %s", productID, cityID)
+	}
+
+	return productInfo, nil
 }
 
-func (s *service) GetProductInfo(ctx context.Context, productID string) (*models.ProductInfo, error) {
-	return s.repo.GetProductInfo(ctx, productID)
+func (s *service) GetQuarantinedOffers(ctx context.Context, workspaceID, productID string) ([]models.QuarantinedOffer, error) {
+	offers, err := s.repo.GetQuarantinedOffers(ctx, workspaceID, productID, 100) // последние 100 записей
+	if err != nil {
+		return nil, storageError(err, "failed to get quarantined offers")
+	}
+	return offers, nil
 }
 
 func (s *service) HealthCheck(ctx context.Context) error {
-	return s.repo.HealthCheck(ctx)
+	if err := s.repo.HealthCheck(ctx); err != nil {
+		return errs.Unavailable(err, "Database is unavailable")
+	}
+	return nil
 }
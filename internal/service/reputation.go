@@ -0,0 +1,82 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// Композитная сила продавца (0..100): байесовский рейтинг, объем покупок и сегмент
+const (
+	reputationPriorReviews  = 50 // вес априорного рейтинга в отзывах
+	reputationRatingWeight  = 0.5
+	reputationVolumeWeight  = 0.3
+	reputationSegmentWeight = 0.2
+)
+
+// rankSellers считает силу каждого продавца и возвращает список по убыванию силы
+func rankSellers(sellers []models.Seller) []models.RankedSeller {
+	ranked := make([]models.RankedSeller, 0, len(sellers))
+	if len(sellers) == 0 {
+		return ranked
+	}
+
+	// Средний рейтинг по выдаче служит априорным значением для продавцов с малым числом отзывов
+	totalRating := 0.0
+	maxPurchases := 0
+	maxSegment := 0.0
+	for _, seller := range sellers {
+		totalRating += seller.Rating
+		if seller.Purchases > maxPurchases {
+			maxPurchases = seller.Purchases
+		}
+		if seller.Segment > maxSegment {
+			maxSegment = seller.Segment
+		}
+	}
+	meanRating := totalRating / float64(len(sellers))
+
+	for _, seller := range sellers {
+		reviews := float64(seller.Reviews)
+		bayesian := (reviews*seller.Rating + reputationPriorReviews*meanRating) / (reviews + reputationPriorReviews)
+
+		volume := 0.0
+		if maxPurchases > 0 {
+			volume = math.Log1p(float64(seller.Purchases)) / math.Log1p(float64(maxPurchases))
+		}
+		segment := 0.0
+		if maxSegment > 0 {
+			segment = seller.Segment / maxSegment
+		}
+
+		score := 100 * (reputationRatingWeight*bayesian/5 + reputationVolumeWeight*volume + reputationSegmentWeight*segment)
+		ranked = append(ranked, models.RankedSeller{
+			Seller:         seller,
+			Score:          math.Round(score*100) / 100,
+			BayesianRating: math.Round(bayesian*1000) / 1000,
+		})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+
+	return ranked
+}
+
+// weightedAvgPrice - средняя цена, взвешенная по силе продавцов
+func weightedAvgPrice(ranked []models.RankedSeller) float64 {
+	var sum, weights float64
+	for _, seller := range ranked {
+		sum += seller.Price * seller.Score
+		weights += seller.Score
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sum / weights
+}
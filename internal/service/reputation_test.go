@@ -0,0 +1,124 @@
+package service
+
+import (
+	"testing"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// rankedByID возвращает результат rankSellers по ID продавца
+func rankedByID(sellers []models.Seller) map[string]models.RankedSeller {
+	ranked := make(map[string]models.RankedSeller, len(sellers))
+	for _, seller := range rankSellers(sellers) {
+		ranked[seller.ID] = seller
+	}
+	return ranked
+}
+
+func TestRankSellersZeroReviewPrior(t *testing.T) {
+	// Средний рейтинг выдачи 4.0: продавец без отзывов получает его, а не свои 5.0
+	ranked := rankedByID([]models.Seller{
+		{ID: "new", Rating: 5, Reviews: 0},
+		{ID: "old", Rating: 3, Reviews: 1000},
+	})
+
+	if got := ranked["new"].BayesianRating; got != 4 {
+		t.Errorf("zero-review bayesian rating %v, want the mean rating 4", got)
+	}
+	if got := ranked["old"].BayesianRating; got <= 3 || got >= 3.1 {
+		t.Errorf("1000-review bayesian rating %v, want close to own rating 3", got)
+	}
+}
+
+func TestRankSellersMonotonic(t *testing.T) {
+	// Третий продавец задает средний рейтинг и максимумы объема и сегмента
+	anchor := models.Seller{ID: "anchor", Rating: 4.5, Reviews: 100, Purchases: 1000, Segment: 3}
+
+	tests := []struct {
+		name          string
+		worse, better models.Seller
+	}{
+		{
+			name:   "higher rating",
+			worse:  models.Seller{Rating: 4.5, Reviews: 100, Purchases: 50, Segment: 2},
+			better: models.Seller{Rating: 4.9, Reviews: 100, Purchases: 50, Segment: 2},
+		},
+		{
+			name:   "more reviews at an above-average rating",
+			worse:  models.Seller{Rating: 4.9, Reviews: 10, Purchases: 50, Segment: 2},
+			better: models.Seller{Rating: 4.9, Reviews: 1000, Purchases: 50, Segment: 2},
+		},
+		{
+			name:   "more purchases",
+			worse:  models.Seller{Rating: 4.5, Reviews: 100, Purchases: 50, Segment: 2},
+			better: models.Seller{Rating: 4.5, Reviews: 100, Purchases: 500, Segment: 2},
+		},
+		{
+			name:   "higher segment",
+			worse:  models.Seller{Rating: 4.5, Reviews: 100, Purchases: 50, Segment: 2},
+			better: models.Seller{Rating: 4.5, Reviews: 100, Purchases: 50, Segment: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.worse.ID, tt.better.ID = "worse", "better"
+			ranked := rankedByID([]models.Seller{tt.worse, tt.better, anchor})
+			if ranked["better"].Score <= ranked["worse"].Score {
+				t.Errorf("score %v, want above %v", ranked["better"].Score, ranked["worse"].Score)
+			}
+			if ranked["better"].Rank >= ranked["worse"].Rank {
+				t.Errorf("rank %d, want ahead of %d", ranked["better"].Rank, ranked["worse"].Rank)
+			}
+		})
+	}
+}
+
+func TestRankSellersOrder(t *testing.T) {
+	ranked := rankSellers(strongCheapSellers())
+	if len(ranked) != 2 || ranked[0].ID != "strong" || ranked[0].Rank != 1 || ranked[1].Rank != 2 {
+		t.Errorf("ranked %+v, want strong first", ranked)
+	}
+	if got := rankSellers(nil); got == nil || len(got) != 0 {
+		t.Errorf("rankSellers(nil) = %#v, want an empty list", got)
+	}
+}
+
+func TestWeightedAvgPrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranked []models.RankedSeller
+		want   float64
+	}{
+		{name: "empty", want: 0},
+		{
+			name: "all zero weights",
+			ranked: []models.RankedSeller{
+				{Seller: models.Seller{Price: 100000}},
+				{Seller: models.Seller{Price: 200000}},
+			},
+			want: 0,
+		},
+		{
+			name: "weighted by score",
+			ranked: []models.RankedSeller{
+				{Seller: models.Seller{Price: 100000}, Score: 75},
+				{Seller: models.Seller{Price: 200000}, Score: 25},
+			},
+			want: 125000,
+		},
+	}
+	for _, tt := range tests {
+		if got := weightedAvgPrice(tt.ranked); got != tt.want {
+			t.Errorf("%s: weightedAvgPrice = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWeightedAvgPriceFallback(t *testing.T) {
+	// Без отзывов, покупок и сегмента у всех продавцов сила 0: взвешенная средняя равна простой
+	sellers := []models.Seller{{ID: "a", Price: 100000}, {ID: "b", Price: 200000}}
+	analysis := newTestService(nil).analyzePrices("p1", sellers)
+	if analysis.WeightedAvgPrice != analysis.AvgPrice {
+		t.Errorf("weighted average %v, want the plain average %v", analysis.WeightedAvgPrice, analysis.AvgPrice)
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"math"
+	"sort"
+)
+
+// sortedPrices возвращает отсортированную копию цен
+func sortedPrices(prices []float64) []float64 {
+	sorted := make([]float64, len(prices))
+	copy(sorted, prices)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// percentile считает p-й перцентиль (0..100) по отсортированным ценам с линейной интерполяцией
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// stdDev считает стандартное отклонение генеральной совокупности
+func stdDev(prices []float64, mean float64) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, price := range prices {
+		sum += (price - mean) * (price - mean)
+	}
+	return math.Sqrt(sum / float64(len(prices)))
+}
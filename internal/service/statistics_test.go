@@ -0,0 +1,84 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		prices []float64
+		p      float64
+		want   float64
+	}{
+		{name: "empty", prices: nil, p: 50, want: 0},
+		{name: "n=1 median", prices: []float64{179990}, p: 50, want: 179990},
+		{name: "n=1 p10", prices: []float64{179990}, p: 10, want: 179990},
+		{name: "n=1 p90", prices: []float64{179990}, p: 90, want: 179990},
+		{name: "even n median interpolates", prices: []float64{100, 200, 300, 400}, p: 50, want: 250},
+		{name: "even n p25", prices: []float64{100, 200, 300, 400}, p: 25, want: 175},
+		{name: "even n p75", prices: []float64{100, 200, 300, 400}, p: 75, want: 325},
+		{name: "odd n median", prices: []float64{100, 200, 900}, p: 50, want: 200},
+		{name: "p0 is the minimum", prices: []float64{100, 200, 900}, p: 0, want: 100},
+		{name: "p100 is the maximum", prices: []float64{100, 200, 900}, p: 100, want: 900},
+		{name: "identical prices", prices: []float64{5000, 5000, 5000, 5000}, p: 90, want: 5000},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.prices, tt.p); got != tt.want {
+			t.Errorf("%s: percentile(%v, %v) = %v, want %v", tt.name, tt.prices, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestStdDev(t *testing.T) {
+	tests := []struct {
+		name   string
+		prices []float64
+		want   float64
+	}{
+		{name: "empty", prices: nil, want: 0},
+		{name: "n=1", prices: []float64{179990}, want: 0},
+		{name: "identical prices", prices: []float64{5000, 5000, 5000}, want: 0},
+		{name: "population deviation", prices: []float64{2, 4, 4, 4, 5, 5, 7, 9}, want: 2},
+		{name: "even n", prices: []float64{100, 300}, want: 100},
+	}
+	for _, tt := range tests {
+		mean := 0.0
+		for _, price := range tt.prices {
+			mean += price
+		}
+		if len(tt.prices) > 0 {
+			mean /= float64(len(tt.prices))
+		}
+		if got := stdDev(tt.prices, mean); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: stdDev(%v) = %v, want %v", tt.name, tt.prices, got, tt.want)
+		}
+	}
+}
+
+func TestSortedPricesCopies(t *testing.T) {
+	prices := []float64{300, 100, 200}
+	if got := sortedPrices(prices); !reflect.DeepEqual(got, []float64{100, 200, 300}) {
+		t.Errorf("sortedPrices = %v", got)
+	}
+	if !reflect.DeepEqual(prices, []float64{300, 100, 200}) {
+		t.Errorf("input reordered to %v", prices)
+	}
+}
+
+func TestAnalyzePricesIdenticalPrices(t *testing.T) {
+	sellers := strongCheapSellers()
+	for i := range sellers {
+		sellers[i].Price = 179990
+	}
+
+	analysis := newTestService(nil).analyzePrices("p1", sellers)
+	if analysis.StdDev != 0 || analysis.IQR != 0 || analysis.PriceSpreadPercent != 0 {
+		t.Errorf("std dev %v, IQR %v, spread %v; want all 0", analysis.StdDev, analysis.IQR, analysis.PriceSpreadPercent)
+	}
+	if analysis.MedianPrice != 179990 || analysis.P10Price != 179990 || analysis.P90Price != 179990 {
+		t.Errorf("median %v, p10 %v, p90 %v; want 179990", analysis.MedianPrice, analysis.P10Price, analysis.P90Price)
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+// Thresholds - пороги анализа. Меняются на работающем сервисе через SetThresholds
+// при изменении config.yaml.
+type Thresholds struct {
+	DumpingRatio  float64 // цена ниже этой доли средней по сегменту - демпинг
+	NoiseScore    float64 // продавцы с силой (0..100) ниже порога - шум, их цены не считаются демпингом
+	OutlierFactor float64 // цена, отличающаяся от медианы больше чем в столько раз, - ошибка парсинга
+}
+
+// DefaultThresholds - пороги по умолчанию
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		DumpingRatio:  0.9,
+		NoiseScore:    30,
+		OutlierFactor: 10,
+	}
+}
+
+// withDefaults заменяет незаданные пороги значениями по умолчанию
+func (t Thresholds) withDefaults() Thresholds {
+	defaults := DefaultThresholds()
+	if t.DumpingRatio <= 0 {
+		t.DumpingRatio = defaults.DumpingRatio
+	}
+	if t.NoiseScore <= 0 {
+		t.NoiseScore = defaults.NoiseScore
+	}
+	if t.OutlierFactor <= 1 {
+		t.OutlierFactor = defaults.OutlierFactor
+	}
+	return t
+}
+
+// SetThresholds заменяет пороги анализа; запросы, начатые раньше, дорабатывают со старыми
+func (s *service) SetThresholds(thresholds Thresholds) {
+	thresholds = thresholds.withDefaults()
+	s.thresholds.Store(&thresholds)
+}
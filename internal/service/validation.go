@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// Причины карантина офферов
+const (
+	reasonNonPositivePrice    = "non_positive_price"
+	reasonPriceOutlier        = "price_outlier"
+	reasonMissingMerchantID   = "missing_merchant_id"
+	reasonDuplicateMerchantID = "duplicate_merchant_id"
+	reasonMasterSkuMismatch   = "master_sku_mismatch"
+	reasonOffersCountMismatch = "offers_count_mismatch"
+)
+
+// Медиана по меньшему числу офферов ненадежна для поиска выбросов
+const outlierMinOffers = 3
+
+// Допуск расхождения offersCount с числом офферов: выдача меняется между запросами страниц,
+// а payload может содержать только первые страницы
+const (
+	offersCountSlack    = 2  // офферов сверх offersCount
+	offersCountMaxRatio = 10 // во сколько раз offersCount может превышать число офферов
+)
+
+// listedOffer - оффер с позицией в выдаче Kaspi (с 1), взятой до отсева в карантин
+type listedOffer struct {
+	models.Offer
+	Position int
+}
+
+// screenOffers отделяет подозрительные офферы от чистых. Цена, отличающаяся от медианы больше
+// чем в outlierFactor раз, считается ошибкой парсинга. Аномалии уровня всего payload
+// (число офферов сильно расходится с offersCount) возвращаются отдельной записью без оффера.
+func screenOffers(request *models.KaspiDataRequest, outlierFactor float64) ([]listedOffer, []models.QuarantinedOffer) {
+	offers := request.Offers.Offers
+	clean := make([]listedOffer, 0, len(offers))
+	var quarantined []models.QuarantinedOffer
+
+	// Медиана положительных цен для поиска выбросов
+	var positive []float64
+	for _, offer := range offers {
+		if offer.Price > 0 {
+			positive = append(positive, offer.Price)
+		}
+	}
+	sort.Float64s(positive)
+	median := percentile(positive, 50)
+
+	seen := make(map[string]bool, len(offers))
+	for i := range offers {
+		offer := offers[i]
+
+		var reasons []string
+		if offer.Price <= 0 {
+			reasons = append(reasons, reasonNonPositivePrice)
+		} else if len(positive) >= outlierMinOffers && (offer.Price > median*outlierFactor || offer.Price < median/outlierFactor) {
+			reasons = append(reasons, reasonPriceOutlier)
+		}
+		if offer.MerchantId == "" {
+			reasons = append(reasons, reasonMissingMerchantID)
+		} else if seen[offer.MerchantId] {
+			reasons = append(reasons, reasonDuplicateMerchantID)
+		}
+		if offer.MasterSku != "" && offer.MasterSku != request.ProductID {
+			reasons = append(reasons, reasonMasterSkuMismatch)
+		}
+		seen[offer.MerchantId] = true
+
+		if len(reasons) == 0 {
+			clean = append(clean, listedOffer{Offer: offer, Position: i + 1})
+			continue
+		}
+		quarantined = append(quarantined, models.QuarantinedOffer{
+			ProductID:  request.ProductID,
+			MerchantID: offer.MerchantId,
+			Price:      offer.Price,
+			Reasons:    reasons,
+			Offer:      &offer,
+		})
+	}
+
+	if offersCountMismatch(request.Offers.OffersCount, len(offers)) {
+		quarantined = append(quarantined, models.QuarantinedOffer{
+			ProductID: request.ProductID,
+			Reasons: []string{
+				fmt.Sprintf("%s: offersCount=%d, offers=%d", reasonOffersCountMismatch, request.Offers.OffersCount, len(offers)),
+			},
+		})
+	}
+
+	return clean, quarantined
+}
+
+// offersCountMismatch сообщает, что число офферов несовместимо с offersCount. Страница выдачи
+// не может содержать больше офферов, чем всего у товара, но и не бывает во много раз меньше
+// его выдачи. Payload без offersCount (0) не проверяется.
+func offersCountMismatch(offersCount, offers int) bool {
+	if offersCount <= 0 {
+		return false
+	}
+	return offers > offersCount+offersCountSlack || offersCount > offers*offersCountMaxRatio
+}
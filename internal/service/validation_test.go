@@ -0,0 +1,208 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"Mini-Quicko/internal/core/models"
+)
+
+// recordedRequest - первый документ json.txt: 3 оффера на первой странице выдачи с offersCount 27
+func recordedRequest(t *testing.T) *models.KaspiDataRequest {
+	t.Helper()
+
+	data, err := os.ReadFile("../../json.txt")
+	if err != nil {
+		t.Fatalf("read json.txt: %v", err)
+	}
+	document := regexp.MustCompile(`(?m)^-{3,}\s*$`).Split(string(data), 2)[0]
+	var request models.KaspiDataRequest
+	if err := json.Unmarshal([]byte(document), &request); err != nil {
+		t.Fatalf("json.txt: %v", err)
+	}
+	return &request
+}
+
+// quarantineReasons возвращает причины карантина по продавцу; запись без оффера - под ключом ""
+func quarantineReasons(quarantined []models.QuarantinedOffer) map[string][]string {
+	reasons := make(map[string][]string, len(quarantined))
+	for _, offer := range quarantined {
+		reasons[offer.MerchantID] = append(reasons[offer.MerchantID], offer.Reasons...)
+	}
+	return reasons
+}
+
+func TestScreenOffersRecordedPayload(t *testing.T) {
+	request := recordedRequest(t)
+	if got := len(request.Offers.Offers); got != 3 || request.Offers.OffersCount != 27 {
+		t.Fatalf("json.txt: %d offers with offersCount %d, want 3 with 27", got, request.Offers.OffersCount)
+	}
+
+	// Первая страница выдачи из 27 офферов - не расхождение с offersCount
+	clean, quarantined := screenOffers(request, DefaultThresholds().OutlierFactor)
+	if len(quarantined) != 0 {
+		t.Errorf("quarantined %+v, want none", quarantined)
+	}
+	for i, offer := range clean {
+		if offer.Position != i+1 {
+			t.Errorf("offer %s at position %d, want %d", offer.MerchantId, offer.Position, i+1)
+		}
+	}
+}
+
+func TestScreenOffers(t *testing.T) {
+	const outlierFactor = 10
+
+	tests := []struct {
+		name          string
+		modify        func(request *models.KaspiDataRequest)
+		wantClean     []string
+		wantPositions []int
+		wantReasons   map[string][]string
+	}{
+		{
+			name:          "price above outlier factor",
+			modify:        func(r *models.KaspiDataRequest) { r.Offers.Offers[2].Price = 179990 * (outlierFactor + 1) },
+			wantClean:     []string{"30358551", "VENDER"},
+			wantPositions: []int{1, 2},
+			wantReasons:   map[string][]string{"18681075": {reasonPriceOutlier}},
+		},
+		{
+			name:          "price below outlier factor",
+			modify:        func(r *models.KaspiDataRequest) { r.Offers.Offers[0].Price = 179990 / (outlierFactor + 1) },
+			wantClean:     []string{"VENDER", "18681075"},
+			wantPositions: []int{2, 3},
+			wantReasons:   map[string][]string{"30358551": {reasonPriceOutlier}},
+		},
+		{
+			name:          "price within outlier factor",
+			modify:        func(r *models.KaspiDataRequest) { r.Offers.Offers[2].Price = 179990 * (outlierFactor - 1) },
+			wantClean:     []string{"30358551", "VENDER", "18681075"},
+			wantPositions: []int{1, 2, 3},
+			wantReasons:   map[string][]string{},
+		},
+		{
+			name:          "duplicate merchant keeps the first offer",
+			modify:        func(r *models.KaspiDataRequest) { r.Offers.Offers[2].MerchantId = "VENDER" },
+			wantClean:     []string{"30358551", "VENDER"},
+			wantPositions: []int{1, 2},
+			wantReasons:   map[string][]string{"VENDER": {reasonDuplicateMerchantID}},
+		},
+		{
+			name: "missing merchant, non-positive price and foreign master SKU",
+			modify: func(r *models.KaspiDataRequest) {
+				r.Offers.Offers[0].MerchantId = ""
+				r.Offers.Offers[1].Price = 0
+				r.Offers.Offers[2].MasterSku = "999"
+			},
+			wantReasons: map[string][]string{
+				"":         {reasonMissingMerchantID},
+				"VENDER":   {reasonNonPositivePrice},
+				"18681075": {reasonMasterSkuMismatch},
+			},
+		},
+		{
+			name:          "payload without offersCount",
+			modify:        func(r *models.KaspiDataRequest) { r.Offers.OffersCount = 0 },
+			wantClean:     []string{"30358551", "VENDER", "18681075"},
+			wantPositions: []int{1, 2, 3},
+			wantReasons:   map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := recordedRequest(t)
+			tt.modify(request)
+
+			clean, quarantined := screenOffers(request, outlierFactor)
+			var ids []string
+			var positions []int
+			for _, offer := range clean {
+				ids = append(ids, offer.MerchantId)
+				positions = append(positions, offer.Position)
+			}
+			if !reflect.DeepEqual(ids, tt.wantClean) || !reflect.DeepEqual(positions, tt.wantPositions) {
+				t.Errorf("clean %v at %v, want %v at %v", ids, positions, tt.wantClean, tt.wantPositions)
+			}
+			if got := quarantineReasons(quarantined); !reflect.DeepEqual(got, tt.wantReasons) {
+				t.Errorf("reasons %v, want %v", got, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestScreenOffersCountMismatch(t *testing.T) {
+	tests := []struct {
+		offersCount int
+		want        bool
+	}{
+		{offersCount: 0},
+		{offersCount: 1},                // 3 оффера при offersCount 1 - в пределах offersCountSlack
+		{offersCount: 27},               // первая страница выдачи
+		{offersCount: 30},               // ровно offersCountMaxRatio
+		{offersCount: 31, want: true},   // офферов во много раз меньше выдачи
+		{offersCount: 1000, want: true}, // тоже
+	}
+	for _, tt := range tests {
+		request := recordedRequest(t)
+		request.Offers.OffersCount = tt.offersCount
+
+		clean, quarantined := screenOffers(request, DefaultThresholds().OutlierFactor)
+		if len(clean) != 3 {
+			t.Errorf("offersCount %d: %d clean offers, want all 3", tt.offersCount, len(clean))
+		}
+		got := len(quarantined) == 1 && quarantined[0].Offer == nil
+		if got != tt.want {
+			t.Errorf("offersCount %d: mismatch recorded = %v, want %v (quarantined %+v)", tt.offersCount, got, tt.want, quarantined)
+		}
+	}
+
+	// Офферов больше, чем offersCount с допуском
+	if !offersCountMismatch(1, 1+offersCountSlack+1) {
+		t.Error("offers above offersCount + slack are not a mismatch")
+	}
+}
+
+func TestDumpingRatio(t *testing.T) {
+	request := recordedRequest(t)
+	var sellers []models.Seller
+	for _, offer := range request.Offers.Offers {
+		sellers = append(sellers, models.Seller{
+			ID:        offer.MerchantId,
+			Rating:    offer.MerchantRating,
+			Reviews:   offer.MerchantReviewsQuantity,
+			Purchases: offer.PurchaseCount,
+			Segment:   3,
+		})
+	}
+	sellers = append(sellers, sellers[0])
+	sellers[3].ID = "cheapest"
+
+	// Средняя по сегменту 152000: 108000 ниже 90% от нее, но выше минимальной + 5%
+	for i, price := range []float64{200000, 200000, 108000, 100000} {
+		sellers[i].Price = price
+	}
+
+	tests := []struct {
+		ratio float64
+		want  []string
+	}{
+		{ratio: 0.9, want: []string{"18681075", "cheapest"}},
+		{ratio: 0.5, want: []string{"cheapest"}}, // минимальная цена сегмента - демпинг при любой доле
+	}
+	for _, tt := range tests {
+		s := newTestService(nil)
+		s.SetThresholds(Thresholds{DumpingRatio: tt.ratio})
+
+		var got []string
+		for _, seller := range s.analyzePrices(request.ProductID, sellers).DumpingSellers {
+			got = append(got, seller.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ratio %v: dumping sellers %v, want %v", tt.ratio, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+)
+
+func (s *service) SaveWatchlistEntry(ctx context.Context, workspaceID string, entry *models.WatchlistEntry) error {
+	entry.WorkspaceID = workspaceID
+	entry.Tags = normalizeTags(entry.Tags)
+	entry.Cities = uniqueStrings(entry.Cities)
+	entry.UpdatedAt = time.Now()
+
+	if err := s.repo.SaveWatchlistEntry(ctx, entry); err != nil {
+		return storageError(err, "failed to save watchlist entry")
+	}
+	return nil
+}
+
+func (s *service) GetWatchlist(ctx context.Context, workspaceID string, tags []string) ([]models.WatchlistEntry, error) {
+	entries, err := s.repo.GetWatchlist(ctx, workspaceID, normalizeTags(tags))
+	if err != nil {
+		return nil, storageError(err, "failed to get watchlist")
+	}
+	return entries, nil
+}
+
+func (s *service) GetWatchlistEntry(ctx context.Context, workspaceID, productID string) (*models.WatchlistEntry, error) {
+	entry, err := s.repo.GetWatchlistEntry(ctx, workspaceID, productID)
+	if err != nil {
+		return nil, storageError(err, "failed to get watchlist entry")
+	}
+
+	if entry == nil {
+		return nil, errs.NotFound("product %s is not in the watchlist", productID)
+	}
+
+	return entry, nil
+}
+
+func (s *service) DeleteWatchlistEntry(ctx context.Context, workspaceID, productID string) error {
+	deleted, err := s.repo.DeleteWatchlistEntry(ctx, workspaceID, productID)
+	if err != nil {
+		return storageError(err, "failed to delete watchlist entry")
+	}
+
+	if !deleted {
+		return errs.NotFound("product %s is not in the watchlist", productID)
+	}
+
+	return nil
+}
+
+// normalizeTags приводит теги к нижнему регистру, убирает пустые и повторы
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
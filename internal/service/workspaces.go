@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/logging"
+)
+
+// Идентификатор рабочего пространства попадает в URL, логи и CLI, поэтому ограничен slug-форматом
+var workspaceIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+func (s *service) CreateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	workspace.ID = strings.ToLower(strings.TrimSpace(workspace.ID))
+	if !workspaceIDPattern.MatchString(workspace.ID) {
+		return errs.Validation("workspace ID must be 1-64 characters of a-z, 0-9, '-' and '_'")
+	}
+
+	workspace.Name = strings.TrimSpace(workspace.Name)
+	if workspace.Name == "" {
+		workspace.Name = workspace.ID
+	}
+	workspace.OwnMerchantID = strings.TrimSpace(workspace.OwnMerchantID)
+	workspace.CreatedAt = time.Now()
+
+	created, err := s.repo.CreateWorkspace(ctx, workspace)
+	if err != nil {
+		return storageError(err, "failed to create workspace")
+	}
+
+	if !created {
+		return errs.Conflict("workspace %s already exists", workspace.ID)
+	}
+
+	return nil
+}
+
+func (s *service) GetWorkspace(ctx context.Context, id string) (*models.Workspace, error) {
+	workspace, err := s.repo.GetWorkspace(ctx, id)
+	if err != nil {
+		return nil, storageError(err, "failed to get workspace")
+	}
+
+	if workspace == nil {
+		return nil, errs.NotFound("workspace %s not found", id)
+	}
+
+	return workspace, nil
+}
+
+func (s *service) ListWorkspaces(ctx context.Context) ([]models.Workspace, error) {
+	workspaces, err := s.repo.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, storageError(err, "failed to list workspaces")
+	}
+	return workspaces, nil
+}
+
+// UpdateWorkspace меняет название и собственный merchant ID; идентификатор не меняется
+func (s *service) UpdateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	workspace.Name = strings.TrimSpace(workspace.Name)
+	if workspace.Name == "" {
+		return errs.Validation("workspace name is required")
+	}
+	workspace.OwnMerchantID = strings.TrimSpace(workspace.OwnMerchantID)
+
+	updated, err := s.repo.UpdateWorkspace(ctx, workspace)
+	if err != nil {
+		return storageError(err, "failed to update workspace")
+	}
+
+	if !updated {
+		return errs.NotFound("workspace %s not found", workspace.ID)
+	}
+
+	return nil
+}
+
+// ownMerchantID - собственный магазин рабочего пространства, а если он не задан - из конфигурации
+func (s *service) ownMerchantID(ctx context.Context, workspaceID string) string {
+	workspace, err := s.repo.GetWorkspace(ctx, workspaceID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get workspace, using configured merchant", "workspace_id", workspaceID, logging.Err(err))
+	}
+	if workspace != nil && workspace.OwnMerchantID != "" {
+		return workspace.OwnMerchantID
+	}
+	return s.settings.OwnMerchantID
+}
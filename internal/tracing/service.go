@@ -0,0 +1,176 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// service открывает span "Service.<метод>" на каждый вызов сервиса
+type service struct {
+	next ports.Service
+}
+
+// InstrumentService оборачивает сервис трассировкой
+func InstrumentService(next ports.Service) ports.Service {
+	return &service{next: next}
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func (s *service) AnalyzeProduct(ctx context.Context, workspaceID, productID, cityID string) (_ *models.ProductAnalysis, err error) {
+	ctx, span := startSpan(ctx, "Service.AnalyzeProduct", attribute.String("workspace.id", workspaceID), attribute.String("product.id", productID), attribute.String("city.id", cityID))
+	defer func() { end(span, err) }()
+	return s.next.AnalyzeProduct(ctx, workspaceID, productID, cityID)
+}
+
+func (s *service) AnalyzeProducts(ctx context.Context, workspaceID string, request *models.BulkAnalysisRequest) (_ *models.BulkAnalysisResult, err error) {
+	ctx, span := startSpan(ctx, "Service.AnalyzeProducts", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.AnalyzeProducts(ctx, workspaceID, request)
+}
+
+func (s *service) GetPriceHistory(ctx context.Context, workspaceID, productID, cityID string) (_ []models.PriceHistory, err error) {
+	ctx, span := startSpan(ctx, "Service.GetPriceHistory", attribute.String("workspace.id", workspaceID), attribute.String("product.id", productID), attribute.String("city.id", cityID))
+	defer func() { end(span, err) }()
+	return s.next.GetPriceHistory(ctx, workspaceID, productID, cityID)
+}
+
+func (s *service) GetProductInfo(ctx context.Context, workspaceID, productID, cityID string) (_ *models.ProductInfo, err error) {
+	ctx, span := startSpan(ctx, "Service.GetProductInfo", attribute.String("workspace.id", workspaceID), attribute.String("product.id", productID), attribute.String("city.id", cityID))
+	defer func() { end(span, err) }()
+	return s.next.GetProductInfo(ctx, workspaceID, productID, cityID)
+}
+
+func (s *service) CompareCities(ctx context.Context, workspaceID, productID string) (_ *models.CityComparison, err error) {
+	ctx, span := startSpan(ctx, "Service.CompareCities", attribute.String("workspace.id", workspaceID), attribute.String("product.id", productID))
+	defer func() { end(span, err) }()
+	return s.next.CompareCities(ctx, workspaceID, productID)
+}
+
+func (s *service) SaveKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (_ *models.ProductAnalysis, err error) {
+	ctx, span := startSpan(ctx, "Service.SaveKaspiData", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.SaveKaspiData(ctx, workspaceID, request)
+}
+
+func (s *service) Backtest(ctx context.Context, workspaceID string, request *models.BacktestRequest) (_ *models.BacktestReport, err error) {
+	ctx, span := startSpan(ctx, "Service.Backtest", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.Backtest(ctx, workspaceID, request)
+}
+
+func (s *service) GetQuarantinedOffers(ctx context.Context, workspaceID, productID string) (_ []models.QuarantinedOffer, err error) {
+	ctx, span := startSpan(ctx, "Service.GetQuarantinedOffers", attribute.String("workspace.id", workspaceID), attribute.String("product.id", productID))
+	defer func() { end(span, err) }()
+	return s.next.GetQuarantinedOffers(ctx, workspaceID, productID)
+}
+
+func (s *service) EnqueueKaspiData(ctx context.Context, workspaceID string, request *models.KaspiDataRequest) (_ *models.Job, err error) {
+	ctx, span := startSpan(ctx, "Service.EnqueueKaspiData", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.EnqueueKaspiData(ctx, workspaceID, request)
+}
+
+func (s *service) GetJob(ctx context.Context, workspaceID string, id int64) (_ *models.Job, err error) {
+	ctx, span := startSpan(ctx, "Service.GetJob", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.GetJob(ctx, workspaceID, id)
+}
+
+func (s *service) SaveWatchlistEntry(ctx context.Context, workspaceID string, entry *models.WatchlistEntry) (err error) {
+	ctx, span := startSpan(ctx, "Service.SaveWatchlistEntry", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.SaveWatchlistEntry(ctx, workspaceID, entry)
+}
+
+func (s *service) GetWatchlist(ctx context.Context, workspaceID string, tags []string) (_ []models.WatchlistEntry, err error) {
+	ctx, span := startSpan(ctx, "Service.GetWatchlist", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.GetWatchlist(ctx, workspaceID, tags)
+}
+
+func (s *service) GetWatchlistEntry(ctx context.Context, workspaceID, productID string) (_ *models.WatchlistEntry, err error) {
+	ctx, span := startSpan(ctx, "Service.GetWatchlistEntry", attribute.String("workspace.id", workspaceID), attribute.String("product.id", productID))
+	defer func() { end(span, err) }()
+	return s.next.GetWatchlistEntry(ctx, workspaceID, productID)
+}
+
+func (s *service) DeleteWatchlistEntry(ctx context.Context, workspaceID, productID string) (err error) {
+	ctx, span := startSpan(ctx, "Service.DeleteWatchlistEntry", attribute.String("workspace.id", workspaceID), attribute.String("product.id", productID))
+	defer func() { end(span, err) }()
+	return s.next.DeleteWatchlistEntry(ctx, workspaceID, productID)
+}
+
+func (s *service) ExportPriceHistory(ctx context.Context, workspaceID string, filter models.HistoryFilter, fn func(models.PriceHistory) error) (err error) {
+	ctx, span := startSpan(ctx, "Service.ExportPriceHistory", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.ExportPriceHistory(ctx, workspaceID, filter, fn)
+}
+
+func (s *service) Purge(ctx context.Context, before time.Time, dryRun bool) (_ *models.PurgeResult, err error) {
+	ctx, span := startSpan(ctx, "Service.Purge")
+	defer func() { end(span, err) }()
+	return s.next.Purge(ctx, before, dryRun)
+}
+
+func (s *service) CreateWorkspace(ctx context.Context, workspace *models.Workspace) (err error) {
+	ctx, span := startSpan(ctx, "Service.CreateWorkspace")
+	defer func() { end(span, err) }()
+	return s.next.CreateWorkspace(ctx, workspace)
+}
+
+func (s *service) GetWorkspace(ctx context.Context, id string) (_ *models.Workspace, err error) {
+	ctx, span := startSpan(ctx, "Service.GetWorkspace")
+	defer func() { end(span, err) }()
+	return s.next.GetWorkspace(ctx, id)
+}
+
+func (s *service) ListWorkspaces(ctx context.Context) (_ []models.Workspace, err error) {
+	ctx, span := startSpan(ctx, "Service.ListWorkspaces")
+	defer func() { end(span, err) }()
+	return s.next.ListWorkspaces(ctx)
+}
+
+func (s *service) UpdateWorkspace(ctx context.Context, workspace *models.Workspace) (err error) {
+	ctx, span := startSpan(ctx, "Service.UpdateWorkspace")
+	defer func() { end(span, err) }()
+	return s.next.UpdateWorkspace(ctx, workspace)
+}
+
+func (s *service) CreateAPIKey(ctx context.Context, workspaceID string, request *models.CreateAPIKeyRequest) (_ *models.NewAPIKey, err error) {
+	ctx, span := startSpan(ctx, "Service.CreateAPIKey", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.CreateAPIKey(ctx, workspaceID, request)
+}
+
+func (s *service) ListAPIKeys(ctx context.Context, workspaceID string) (_ []models.APIKey, err error) {
+	ctx, span := startSpan(ctx, "Service.ListAPIKeys", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.ListAPIKeys(ctx, workspaceID)
+}
+
+func (s *service) RevokeAPIKey(ctx context.Context, workspaceID string, id int64) (err error) {
+	ctx, span := startSpan(ctx, "Service.RevokeAPIKey", attribute.String("workspace.id", workspaceID))
+	defer func() { end(span, err) }()
+	return s.next.RevokeAPIKey(ctx, workspaceID, id)
+}
+
+func (s *service) Authenticate(ctx context.Context, key string) (_ *models.APIKey, err error) {
+	ctx, span := startSpan(ctx, "Service.Authenticate")
+	defer func() { end(span, err) }()
+	return s.next.Authenticate(ctx, key)
+}
+
+func (s *service) HealthCheck(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "Service.HealthCheck")
+	defer func() { end(span, err) }()
+	return s.next.HealthCheck(ctx)
+}
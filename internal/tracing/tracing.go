@@ -0,0 +1,90 @@
+// Package tracing передает трассировки OpenTelemetry: span на HTTP запрос, метод сервиса и метод
+// репозитория. Контекст трассировки принимается из заголовков W3C traceparent/tracestate.
+//
+// Сервис и репозиторий оборачиваются декораторами InstrumentService и InstrumentRepository,
+// поэтому реализации портов о трассировке не знают.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"Mini-Quicko/internal/core/errs"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "Mini-Quicko"
+
+// Config - настройки экспорта трассировок
+type Config struct {
+	Exporter    string  // none, otlp или stdout
+	Endpoint    string  // адрес OTLP/HTTP коллектора, например otel-collector:4318
+	Insecure    bool    // OTLP без TLS
+	ServiceName string  // service.name в трассировках
+	SampleRatio float64 // доля трассируемых запросов без родительского span, от 0 до 1
+}
+
+// Setup настраивает глобальный TracerProvider и W3C propagator. Возвращает функцию,
+// которая отправляет накопленные span при остановке сервера.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q: use none, otlp or stdout", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// end завершает span. Ошибками span отмечаются только внутренние ошибки и недоступность БД:
+// "не найдено" и ошибки проверки - нормальные ответы.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if kind := errs.KindOf(err); kind == errs.KindInternal || kind == errs.KindUnavailable {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,202 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"Mini-Quicko/internal/core/errs"
+	"Mini-Quicko/internal/core/models"
+	"Mini-Quicko/internal/core/ports"
+	"Mini-Quicko/internal/logging"
+)
+
+// Config - параметры пула воркеров очереди загрузки
+type Config struct {
+	Workers      int
+	PollInterval time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Lease        time.Duration // срок аренды задачи, продлевается пока задача выполняется
+}
+
+// Pool разбирает задачи из таблицы ingestion_jobs и прогоняет их через SaveKaspiData
+type Pool struct {
+	repo    ports.Repository
+	service ports.Service
+	cfg     Config
+
+	cancel     context.CancelFunc // останавливает разбор очереди
+	cancelJobs context.CancelFunc // прерывает текущие задачи, если они не успели завершиться
+	wg         sync.WaitGroup
+}
+
+func NewPool(repo ports.Repository, service ports.Service, cfg Config) *Pool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 2 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 2 * time.Minute
+	}
+
+	return &Pool{
+		repo:    repo,
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// Start запускает воркеры. Воркер берет задачу в аренду на Lease и продлевает ее, пока задача
+// выполняется; задачи упавших реплик забираются после истечения аренды, а задачи живых
+// реплик не трогаются (в том числе при rolling deploy).
+func (p *Pool) Start(ctx context.Context) error {
+	// Текущие задачи не прерываются вместе с разбором очереди, а дорабатывают до срока Shutdown
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.cancelJobs = cancelJobs
+	for i := 0; i < p.cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.run(ctx, jobCtx)
+	}
+
+	slog.InfoContext(ctx, "ingestion worker pool started", "workers", p.cfg.Workers)
+	return nil
+}
+
+// Shutdown перестает брать новые задачи и ждет завершения текущих. Если ctx истекает раньше,
+// текущие задачи прерываются и возвращаются в очередь как неудачная попытка.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		p.cancelJobs()
+		<-done
+		return fmt.Errorf("ingestion jobs were interrupted: %w", ctx.Err())
+	}
+}
+
+func (p *Pool) run(ctx, jobCtx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		job, err := p.repo.ClaimJob(ctx, p.cfg.Lease)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "failed to claim ingestion job", logging.Err(err))
+		}
+
+		// Очередь пуста или БД недоступна - ждем следующего опроса
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.cfg.PollInterval):
+			}
+			continue
+		}
+
+		p.process(jobCtx, job)
+	}
+}
+
+func (p *Pool) process(ctx context.Context, job *models.Job) {
+	// Логи сервиса и репозитория во время обработки получают идентификаторы задачи
+	ctx = logging.With(ctx, "job_id", job.ID, "workspace_id", job.WorkspaceID, "product_id", job.ProductID)
+	stopHeartbeat := p.heartbeat(ctx, job)
+	analysis, err := p.execute(ctx, job)
+	stopHeartbeat()
+
+	now := time.Now()
+	job.UpdatedAt = now
+	switch {
+	case err == nil:
+		job.Status = models.JobStatusSucceeded
+		job.Result = analysis
+		job.Error = ""
+		job.FinishedAt = &now
+	// Некорректные данные при повторе не исправятся, такие задачи сразу завершаются ошибкой
+	case job.Attempts < job.MaxAttempts && !errs.Is(err, errs.KindValidation):
+		job.Status = models.JobStatusQueued
+		job.Error = errs.Message(err)
+		job.RunAt = now.Add(p.backoff(job.Attempts))
+		slog.WarnContext(ctx, "ingestion job failed, retrying",
+			"attempt", job.Attempts, "max_attempts", job.MaxAttempts, "run_at", job.RunAt, logging.Err(err))
+	default:
+		job.Status = models.JobStatusFailed
+		job.Error = errs.Message(err)
+		job.FinishedAt = &now
+		slog.ErrorContext(ctx, "ingestion job failed permanently", "attempts", job.Attempts, logging.Err(err))
+	}
+
+	// Статус сохраняем даже при остановке пула, чтобы задача не зависла в running
+	if err := p.repo.UpdateJob(context.WithoutCancel(ctx), job); err != nil {
+		slog.ErrorContext(ctx, "failed to update ingestion job", logging.Err(err))
+	}
+}
+
+func (p *Pool) execute(ctx context.Context, job *models.Job) (*models.ProductAnalysis, error) {
+	var request models.KaspiDataRequest
+	if err := json.Unmarshal(job.Payload, &request); err != nil {
+		return nil, errs.Validation("invalid job payload: %v", err)
+	}
+
+	return p.service.SaveKaspiData(ctx, job.WorkspaceID, &request)
+}
+
+// heartbeat продлевает аренду задачи каждую треть срока, пока не вызвана возвращенная функция
+func (p *Pool) heartbeat(ctx context.Context, job *models.Job) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(p.cfg.Lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.repo.ExtendJobLease(ctx, job, time.Now().Add(p.cfg.Lease)); err != nil && ctx.Err() == nil {
+					slog.WarnContext(ctx, "failed to extend ingestion job lease", logging.Err(err))
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// backoff - экспоненциальная задержка перед повторной попыткой: base, 2*base, 4*base, ...
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.cfg.BaseBackoff
+	for i := 1; i < attempt && delay < p.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.cfg.MaxBackoff)
+}